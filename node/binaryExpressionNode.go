@@ -11,11 +11,19 @@
 
 package node
 
-import "strings"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"sangupta.com/velocity/utils"
+)
 
 type BinaryExpressionNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Lhs          ExpressionNode
 	Rhs          ExpressionNode
 	Operator     Operator
@@ -30,6 +38,10 @@ func (cons *BinaryExpressionNode) GetLineNumber() uint {
 	return cons.LineNumber
 }
 
+func (cons *BinaryExpressionNode) GetColumnNumber() uint {
+	return cons.ColumnNumber
+}
+
 func (node *BinaryExpressionNode) String() string {
 	return ""
 }
@@ -57,21 +69,144 @@ func (node *BinaryExpressionNode) IsTrue(context *EvaluationContext) bool {
 func (node *BinaryExpressionNode) Evaluate(context *EvaluationContext) interface{} {
 	switch node.Operator {
 	case OR:
-		return node.Lhs.IsTrue(context) || node.Rhs.IsTrue(context)
+		return isExpressionDefinedAndTrue(node.Lhs, context) || isExpressionDefinedAndTrue(node.Rhs, context)
 
 	case AND:
-		return node.Lhs.IsTrue(context) && node.Rhs.IsTrue(context)
+		return isExpressionDefinedAndTrue(node.Lhs, context) && isExpressionDefinedAndTrue(node.Rhs, context)
 
 	case EQUAL:
 		return node.equal(context)
 
 	case NOT_EQUAL:
 		return !node.equal(context)
+
+	case LESS:
+		return node.compare(context) < 0
+
+	case LESS_OR_EQUAL:
+		return node.compare(context) <= 0
+
+	case GREATER:
+		return node.compare(context) > 0
+
+	case GREATER_OR_EQUAL:
+		return node.compare(context) >= 0
+
+	case PLUS, MINUS, TIMES, DIVIDE, REMAINDER:
+		return node.arithmetic(context)
 	}
 
 	return nil
 }
 
+/**
+ * Compares the values of the operands for one of the operators {@code < <= > >=},
+ * returning a negative number, zero or a positive number as the left value is less than,
+ * equal to or greater than the right one. Numbers of any types are compared by value,
+ * strings lexicographically and {@code time.Time} values chronologically. Otherwise one
+ * of the values must be a `Comparable` that accepts the other.
+ */
+func (node *BinaryExpressionNode) compare(context *EvaluationContext) int {
+	leftValue := node.Lhs.Evaluate(context)
+	rightValue := node.Rhs.Evaluate(context)
+
+	left, isLeftNumber := utils.AsNumber(leftValue)
+	right, isRightNumber := utils.AsNumber(rightValue)
+	if isLeftNumber && isRightNumber {
+		return left.Compare(right)
+	}
+
+	leftString, isLeftString := leftValue.(string)
+	rightString, isRightString := rightValue.(string)
+	if isLeftString && isRightString {
+		return strings.Compare(leftString, rightString)
+	}
+
+	leftTime, isLeftTime := leftValue.(time.Time)
+	rightTime, isRightTime := rightValue.(time.Time)
+	if isLeftTime && isRightTime {
+		switch {
+		case leftTime.Before(rightTime):
+			return -1
+
+		case leftTime.After(rightTime):
+			return 1
+		}
+
+		return 0
+	}
+
+	leftComparable, ok := leftValue.(Comparable)
+	if ok {
+		result, isComparable := leftComparable.CompareTo(rightValue)
+		if isComparable {
+			return result
+		}
+	}
+
+	rightComparable, ok := rightValue.(Comparable)
+	if ok {
+		result, isComparable := rightComparable.CompareTo(leftValue)
+		if isComparable {
+			return -result
+		}
+	}
+
+	message := fmt.Sprintf("Cannot compare %T with %T using %s", leftValue, rightValue, node.Operator.Symbol)
+	panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
+}
+
+/**
+ * Applies one of the arithmetic operators {@code + - * / %} to the values of the operands,
+ * which must be numbers, promoted to a common type as described for `utils.Number`. As in
+ * Velocity, {@code +} concatenates instead if either operand is a string.
+ */
+func (node *BinaryExpressionNode) arithmetic(context *EvaluationContext) interface{} {
+	leftValue := node.Lhs.Evaluate(context)
+	rightValue := node.Rhs.Evaluate(context)
+
+	if node.Operator == PLUS {
+		_, isLeftString := leftValue.(string)
+		_, isRightString := rightValue.(string)
+		if isLeftString || isRightString {
+			return utils.AsString(leftValue) + utils.AsString(rightValue)
+		}
+	}
+
+	left, isLeftNumber := utils.AsNumber(leftValue)
+	right, isRightNumber := utils.AsNumber(rightValue)
+	if !isLeftNumber || !isRightNumber {
+		message := fmt.Sprintf("Arithmetic is only available on numbers, not %T %s %T", leftValue, node.Operator.Symbol, rightValue)
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
+	}
+
+	var result utils.Number
+	var err error
+	switch node.Operator {
+	case PLUS:
+		result, err = left.Add(right)
+
+	case MINUS:
+		result, err = left.Subtract(right)
+
+	case TIMES:
+		result, err = left.Multiply(right)
+
+	case DIVIDE:
+		result, err = left.Divide(right)
+
+	default:
+		result, err = left.Remainder(right)
+	}
+
+	if err != nil {
+		message := fmt.Sprintf("Cannot evaluate %v %s %v", left.Value(), node.Operator.Symbol, right.Value())
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, err))
+	}
+
+	return result.Value()
+}
+
 /**
  * Returns true if {@code lhs} and {@code rhs} are equal according to Velocity.
  *
@@ -89,23 +224,45 @@ func (node *BinaryExpressionNode) equal(context *EvaluationContext) bool {
 	leftValue := node.Lhs.Evaluate(context)
 	rightValue := node.Rhs.Evaluate(context)
 
-	// todo fix this
-	if leftValue == nil || rightValue == nil {
-		return false
+	return velocityEquals(leftValue, rightValue)
+}
+
+/**
+ * Returns true if two values are equal according to Velocity, as described for
+ * `BinaryExpressionNode.equal`. Adapted to Go, the rules are:
+ *
+ * <ul>
+ *   <li>nil, or a nil pointer, is only equal to nil;
+ *   <li>numbers are equal if they have the same value, whatever their Go types, NaN
+ *       being equal to nothing;
+ *   <li>values of the same type are equal if they are deeply equal, so that slices and
+ *       maps can be compared;
+ *   <li>values of different types are equal if they render as the same string.
+ * </ul>
+ */
+func velocityEquals(leftValue interface{}, rightValue interface{}) bool {
+	if isNil(leftValue) || isNil(rightValue) {
+		return isNil(leftValue) && isNil(rightValue)
 	}
 
-	if leftValue == rightValue {
-		return true
+	left, isLeftNumber := utils.AsNumber(leftValue)
+	right, isRightNumber := utils.AsNumber(rightValue)
+	if isLeftNumber && isRightNumber {
+		return left.Equals(right)
 	}
 
-	// todo fix this
-	return false
+	if reflect.TypeOf(leftValue) == reflect.TypeOf(rightValue) {
+		return reflect.DeepEqual(leftValue, rightValue)
+	}
+
+	return utils.AsString(leftValue) == utils.AsString(rightValue)
 }
 
 func NewBinaryExpressionNode(lhs ExpressionNode, op Operator, rhs ExpressionNode) *BinaryExpressionNode {
 	return &BinaryExpressionNode{
 		ResourceName: lhs.GetResourceName(),
 		LineNumber:   lhs.GetLineNumber(),
+		ColumnNumber: lhs.GetColumnNumber(),
 		Lhs:          lhs,
 		Rhs:          rhs,
 		Operator:     op,
@@ -0,0 +1,109 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * A node in the parse tree representing a {@code #break} directive. A plain {@code #break}
+ * leaves the innermost {@code #foreach} or macro call. With an argument, as in
+ * {@code #break ($foreach.parent)} or {@code #break ($macro)}, it leaves the scope given
+ * by the argument, and any scopes nested inside it.
+ */
+type BreakNode struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Scope        ExpressionNode
+	Type         string
+}
+
+func (node *BreakNode) String() string {
+	return ""
+}
+
+func (node *BreakNode) IsWhitespace() bool {
+	return false
+}
+
+func (node *BreakNode) IsHorizontalWhitespace() bool {
+	return false
+}
+
+func (node *BreakNode) MarkDirectiveNode() {
+
+}
+
+func (node *BreakNode) Render(context *EvaluationContext, output *strings.Builder) {
+	if node.Scope == nil {
+		// only a resource parsed at runtime can get here outside of any scope, as
+		// the parser checks where #break appears in everything else
+		if context.breakableDepth == 0 {
+			panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "#break is only allowed inside #foreach or #macro", nil))
+		}
+
+		panic(&breakSignal{})
+	}
+
+	scope := node.Scope.Evaluate(context)
+	switch scope.(type) {
+	case *ForEachScope, *MacroScope:
+		panic(&breakSignal{scope: scope})
+	}
+
+	message := fmt.Sprintf("#break argument must be a #foreach or macro scope, not %T", scope)
+	panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
+}
+
+func NewBreakNode(resourceName string, lineNumber uint, columnNumber uint, scope ExpressionNode) *BreakNode {
+	return &BreakNode{
+		ResourceName: resourceName,
+		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
+		Scope:        scope,
+		Type:         "Break",
+	}
+}
+
+/**
+ * Raised by a {@code #break} and recovered by the {@code #foreach} or macro call it leaves.
+ * A nil scope is recovered by the innermost one. If nothing recovers it, the template
+ * evaluation fails with it as an error.
+ */
+type breakSignal struct {
+	scope interface{}
+}
+
+func (signal *breakSignal) Error() string {
+	return "#break outside of the scope it leaves"
+}
+
+/**
+ * Recovers a `breakSignal` that leaves the given scope. Any other panic, including a
+ * `breakSignal` for an outer scope, continues. Must be called directly by `defer`.
+ */
+func recoverBreak(scope interface{}) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	signal, ok := recovered.(*breakSignal)
+	if ok && (signal.scope == nil || signal.scope == scope) {
+		return
+	}
+
+	panic(recovered)
+}
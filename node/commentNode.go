@@ -16,6 +16,7 @@ import "strings"
 type CommentNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Type         string
 }
 
@@ -35,10 +36,11 @@ func (node *CommentNode) Render(context *EvaluationContext, output *strings.Buil
 
 }
 
-func NewCommentNode(name string, line uint) *CommentNode {
+func NewCommentNode(name string, line uint, column uint) *CommentNode {
 	return &CommentNode{
 		ResourceName: name,
 		LineNumber:   line,
+		ColumnNumber: column,
 		Type:         "Comment",
 	}
 }
@@ -0,0 +1,22 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+/**
+ * Implemented by values that can be ordered by the operators {@code < <= > >=}, like
+ * {@code java.lang.Comparable}. `CompareTo` returns a negative number, zero or a positive
+ * number as the value is less than, equal to or greater than the other, and false if the
+ * two values cannot be compared.
+ */
+type Comparable interface {
+	CompareTo(other interface{}) (int, bool)
+}
@@ -19,6 +19,7 @@ import (
 type ConsNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Children     []Node
 	Type         string
 }
@@ -45,18 +46,20 @@ func (node *ConsNode) Render(context *EvaluationContext, output *strings.Builder
 	}
 }
 
-func NewConsNode(name string, line uint, children []Node) *ConsNode {
+func NewConsNode(name string, line uint, column uint, children []Node) *ConsNode {
 	return &ConsNode{
 		ResourceName: name,
 		LineNumber:   line,
+		ColumnNumber: column,
 		Children:     children,
 	}
 }
 
-func EmptyNode(resourceName string, lineNumber uint) *ConsNode {
+func EmptyNode(resourceName string, lineNumber uint, columnNumber uint) *ConsNode {
 	return &ConsNode{
 		ResourceName: resourceName,
 		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
 		Children:     make([]Node, 0),
 		Type:         "Constant",
 	}
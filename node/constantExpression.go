@@ -21,6 +21,7 @@ import (
 type ConstantExpressionNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Value        interface{}
 	Type         string
 }
@@ -33,6 +34,10 @@ func (node *ConstantExpressionNode) GetLineNumber() uint {
 	return node.LineNumber
 }
 
+func (node *ConstantExpressionNode) GetColumnNumber() uint {
+	return node.ColumnNumber
+}
+
 func (node *ConstantExpressionNode) IsTrue(context *EvaluationContext) bool {
 	return isExpressionTrue(node, context)
 }
@@ -42,7 +47,7 @@ func (node *ConstantExpressionNode) Render(context *EvaluationContext, output *s
 }
 
 func (node *ConstantExpressionNode) Evaluate(context *EvaluationContext) interface{} {
-	return node.String()
+	return node.Value
 }
 
 func (node *ConstantExpressionNode) String() string {
@@ -88,11 +93,12 @@ func (node *ConstantExpressionNode) MarkExpressionNode() {
 
 }
 
-func NewConstantExpressionNode(resourceName string, lineNumber uint, expression string) *ConstantExpressionNode {
+func NewConstantExpressionNode(resourceName string, lineNumber uint, columnNumber uint, value interface{}) *ConstantExpressionNode {
 	return &ConstantExpressionNode{
 		ResourceName: resourceName,
 		LineNumber:   lineNumber,
-		Value:        expression,
+		ColumnNumber: columnNumber,
+		Value:        value,
 		Type:         "ConstantExpression",
 	}
 }
@@ -14,7 +14,10 @@ package node
 import "errors"
 
 type EvaluationContext struct {
-	Variables map[string]interface{}
+	Variables      map[string]interface{}
+	Runtime        Runtime
+	parseDepth     int
+	breakableDepth int
 }
 
 func (context *EvaluationContext) IsVarDefined(id string) bool {
@@ -56,3 +59,19 @@ func (context *EvaluationContext) SetVar(id string, value interface{}) func() {
 func (context *EvaluationContext) Remove(id string) {
 	delete(context.Variables, id)
 }
+
+/**
+ * Removes a variable from the context and returns an `undo` function
+ * that gives it back the value it had, if any.
+ */
+func (context *EvaluationContext) UnsetVar(id string) func() {
+	oldValue, isDefined := context.Variables[id]
+	if !isDefined {
+		return func() {}
+	}
+
+	context.Remove(id)
+	return func() {
+		context.SetVar(id, oldValue)
+	}
+}
@@ -0,0 +1,64 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import "strings"
+
+/**
+ * A node in the parse tree representing the use of a `Directive` registered with the parser.
+ */
+type CustomDirectiveNode struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Name         string
+	Directive    Directive `json:"-"`
+	Arguments    []ExpressionNode
+	Body         Node
+	Type         string
+}
+
+func (node *CustomDirectiveNode) String() string {
+	return ""
+}
+
+func (node *CustomDirectiveNode) IsWhitespace() bool {
+	return false
+}
+
+func (node *CustomDirectiveNode) IsHorizontalWhitespace() bool {
+	return false
+}
+
+func (node *CustomDirectiveNode) MarkDirectiveNode() {
+
+}
+
+func (node *CustomDirectiveNode) Render(context *EvaluationContext, output *strings.Builder) {
+	err := node.Directive.Render(context, output, node.Arguments, node.Body)
+	if err != nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "error in #"+node.Name, err))
+	}
+}
+
+func NewCustomDirectiveNode(resourceName string, lineNumber uint, columnNumber uint, directive Directive, arguments []ExpressionNode, body Node) *CustomDirectiveNode {
+	return &CustomDirectiveNode{
+		ResourceName: resourceName,
+		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
+		Name:         directive.GetName(),
+		Directive:    directive,
+		Arguments:    arguments,
+		Body:         body,
+		Type:         "CustomDirective",
+	}
+}
@@ -0,0 +1,107 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * How deeply the rendering of a block set by {@code #define} may be nested within itself,
+ * which as in Velocity protects against a block that references itself.
+ */
+const MAX_DEFINE_DEPTH = 2
+
+/**
+ * A node in the parse tree representing a {@code #define} construct. Evaluating
+ * {@code #define ($block) body #end} sets {@code $block} to a `DefinedBlock` for the body,
+ * without rendering it. The body is rendered, against the context as it is at that time,
+ * each time {@code $block} is output.
+ */
+type DefineNode struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Variable     string
+	Body         Node
+	Type         string
+}
+
+func (node *DefineNode) String() string {
+	return ""
+}
+
+func (node *DefineNode) IsWhitespace() bool {
+	return false
+}
+
+func (node *DefineNode) IsHorizontalWhitespace() bool {
+	return false
+}
+
+func (node *DefineNode) MarkDirectiveNode() {
+
+}
+
+func (node *DefineNode) Render(context *EvaluationContext, output *strings.Builder) {
+	context.SetVar(node.Variable, NewDefinedBlock(node, context))
+}
+
+func NewDefineNode(resourceName string, lineNumber uint, columnNumber uint, id string, body Node) *DefineNode {
+	return &DefineNode{
+		ResourceName: resourceName,
+		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
+		Variable:     id,
+		Body:         body,
+		Type:         "Define",
+	}
+}
+
+/**
+ * The value of a reference set by {@code #define}. Rendering it, through `String`, renders
+ * the body of the {@code #define} against the evaluation context. A body that renders the
+ * block again may do so up to `MAX_DEFINE_DEPTH` times.
+ */
+type DefinedBlock struct {
+	Body       Node
+	definition *DefineNode
+	context    *EvaluationContext
+	depth      int
+}
+
+func (block *DefinedBlock) String() string {
+	if block.depth >= MAX_DEFINE_DEPTH {
+		definition := block.definition
+		message := fmt.Sprintf("$%s exceeds the maximum #define depth of %d", definition.Variable, MAX_DEFINE_DEPTH)
+		panic(NewEvaluationError(definition.ResourceName, definition.LineNumber, definition.ColumnNumber, message, nil))
+	}
+
+	block.depth++
+	defer func() {
+		block.depth--
+	}()
+
+	builder := strings.Builder{}
+	block.Body.Render(block.context, &builder)
+
+	return builder.String()
+}
+
+func NewDefinedBlock(definition *DefineNode, context *EvaluationContext) *DefinedBlock {
+	return &DefinedBlock{
+		Body:       definition.Body,
+		definition: definition,
+		context:    context,
+	}
+}
@@ -0,0 +1,59 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import "strings"
+
+/**
+ * Says whether a custom directive has a body.
+ */
+type DirectiveKind int
+
+const (
+	/**
+	 * A directive without a body, like {@code #now} or {@code #now ($format)}.
+	 */
+	LINE_DIRECTIVE DirectiveKind = iota
+
+	/**
+	 * A directive with a body ending at the matching {@code #end}, like
+	 * {@code #cache ($key) body #end}.
+	 */
+	BLOCK_DIRECTIVE
+)
+
+/**
+ * A directive implemented in Go and registered with the parser, so that templates can use
+ * it as {@code #name}, {@code #name (arg1 arg2)} or {@code #name (arg1, arg2)}. The arguments
+ * are parsed like those of a macro call. A directive of kind `BLOCK_DIRECTIVE` also has a body,
+ * which it renders as it sees fit.
+ */
+type Directive interface {
+	/**
+	 * The name of the directive, as used after {@code #}.
+	 */
+	GetName() string
+
+	GetKind() DirectiveKind
+
+	/**
+	 * Checks the arguments of a use of the directive when it is parsed. Returning an
+	 * error makes the template fail to parse.
+	 */
+	CheckArguments(arguments []ExpressionNode) error
+
+	/**
+	 * Renders a use of the directive. The arguments are not evaluated beforehand, and the
+	 * body is nil for a `LINE_DIRECTIVE`. Returning an error makes the evaluation fail.
+	 */
+	Render(context *EvaluationContext, output *strings.Builder, arguments []ExpressionNode, body Node) error
+}
@@ -16,11 +16,12 @@ import "strings"
 type ElseIfNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Type         string
 }
 
 func (node *ElseIfNode) String() string {
-	return ""
+	return "#elseif"
 }
 
 func (node *ElseIfNode) IsWhitespace() bool {
@@ -39,10 +40,11 @@ func (node *ElseIfNode) Render(context *EvaluationContext, output *strings.Build
 
 }
 
-func NewElseIfNode(name string, line uint) *ElseIfNode {
+func NewElseIfNode(name string, line uint, column uint) *ElseIfNode {
 	return &ElseIfNode{
 		ResourceName: name,
 		LineNumber:   line,
+		ColumnNumber: column,
 		Type:         "ElseIf",
 	}
 }
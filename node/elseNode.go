@@ -16,11 +16,12 @@ import "strings"
 type ElseNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Type         string
 }
 
 func (node *ElseNode) String() string {
-	return ""
+	return "#else"
 }
 
 func (node *ElseNode) IsWhitespace() bool {
@@ -39,10 +40,11 @@ func (node *ElseNode) Render(context *EvaluationContext, output *strings.Builder
 
 }
 
-func NewElseNode(name string, line uint) *ElseNode {
+func NewElseNode(name string, line uint, column uint) *ElseNode {
 	return &ElseNode{
 		ResourceName: name,
 		LineNumber:   line,
+		ColumnNumber: column,
 		Type:         "Else",
 	}
 }
@@ -16,11 +16,12 @@ import "strings"
 type EndNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Type         string
 }
 
 func (node *EndNode) String() string {
-	return ""
+	return "#end"
 }
 
 func (node *EndNode) IsWhitespace() bool {
@@ -39,10 +40,11 @@ func (node *EndNode) Render(context *EvaluationContext, output *strings.Builder)
 
 }
 
-func NewEndNode(name string, line uint) *EndNode {
+func NewEndNode(name string, line uint, column uint) *EndNode {
 	return &EndNode{
 		ResourceName: name,
 		LineNumber:   line,
+		ColumnNumber: column,
 		Type:         "End",
 	}
 }
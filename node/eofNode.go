@@ -16,11 +16,12 @@ import "strings"
 type EofNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Type         string
 }
 
 func (node *EofNode) String() string {
-	return ""
+	return "end of file"
 }
 
 func (node *EofNode) IsWhitespace() bool {
@@ -39,10 +40,11 @@ func (node *EofNode) Render(context *EvaluationContext, output *strings.Builder)
 
 }
 
-func NewEofNode(resourceName string, lineNumber uint) *EofNode {
+func NewEofNode(resourceName string, lineNumber uint, columnNumber uint) *EofNode {
 	return &EofNode{
 		ResourceName: resourceName,
 		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
 		Type:         "EOF",
 	}
 }
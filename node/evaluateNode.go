@@ -0,0 +1,105 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"sangupta.com/velocity/utils"
+)
+
+/**
+ * A node in the parse tree representing an {@code #evaluate} directive, like
+ * {@code #evaluate ($snippet)}. The argument is evaluated to a string, which is parsed as a
+ * template and rendered against the current context. A null argument renders nothing.
+ *
+ * <p>Errors in the snippet are reported relative to the snippet itself, whose resource name
+ * is {@code #evaluate}, wrapped in an error giving the position of the directive.
+ */
+type EvaluateNode struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Argument     ExpressionNode
+	Type         string
+}
+
+func (node *EvaluateNode) String() string {
+	return ""
+}
+
+func (node *EvaluateNode) IsWhitespace() bool {
+	return false
+}
+
+func (node *EvaluateNode) IsHorizontalWhitespace() bool {
+	return false
+}
+
+func (node *EvaluateNode) MarkDirectiveNode() {
+
+}
+
+func (node *EvaluateNode) Render(context *EvaluationContext, output *strings.Builder) {
+	value := node.Argument.Evaluate(context)
+	if value == nil {
+		return
+	}
+
+	if context.Runtime == nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "cannot #evaluate without a runtime", nil))
+	}
+
+	if context.parseDepth >= MAX_PARSE_DEPTH {
+		message := fmt.Sprintf("#evaluate exceeds the maximum depth of %d", MAX_PARSE_DEPTH)
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
+	}
+
+	parsed, err := context.Runtime.ParseString(utils.AsString(value))
+	if err != nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "cannot #evaluate", err))
+	}
+
+	context.parseDepth++
+	defer func() {
+		context.parseDepth--
+
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		switch cause := recovered.(type) {
+		case *breakSignal, runtime.Error:
+			panic(recovered)
+
+		case error:
+			panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "error in #evaluate", cause))
+		}
+
+		panic(recovered)
+	}()
+
+	parsed.Render(context, output)
+}
+
+func NewEvaluateNode(resourceName string, lineNumber uint, columnNumber uint, argument ExpressionNode) *EvaluateNode {
+	return &EvaluateNode{
+		ResourceName: resourceName,
+		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
+		Argument:     argument,
+		Type:         "Evaluate",
+	}
+}
@@ -0,0 +1,50 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import "fmt"
+
+/**
+ * The error raised when a template cannot be evaluated, recording the
+ * resource and position of the node that failed and, if there is one,
+ * the underlying error that caused the failure.
+ */
+type EvaluationError struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Message      string
+	Cause        error
+}
+
+func (err *EvaluationError) Error() string {
+	message := fmt.Sprintf("%s:%d:%d: %s", err.ResourceName, err.LineNumber, err.ColumnNumber, err.Message)
+	if err.Cause != nil {
+		message += ": " + err.Cause.Error()
+	}
+
+	return message
+}
+
+func (err *EvaluationError) Unwrap() error {
+	return err.Cause
+}
+
+func NewEvaluationError(resourceName string, lineNumber uint, columnNumber uint, message string, cause error) *EvaluationError {
+	return &EvaluationError{
+		ResourceName: resourceName,
+		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
+		Message:      message,
+		Cause:        cause,
+	}
+}
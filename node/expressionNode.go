@@ -25,6 +25,7 @@ type ExpressionNode interface {
 	Node
 	GetResourceName() string
 	GetLineNumber() uint
+	GetColumnNumber() uint
 	IsTrue(context *EvaluationContext) bool
 	Evaluate(context *EvaluationContext) interface{}
 	MarkExpressionNode()
@@ -72,6 +73,11 @@ func isExpressionTrue(node ExpressionNode, context *EvaluationContext) bool {
  * if {@code $var} is undefined.
  */
 func isExpressionDefinedAndTrue(node ExpressionNode, context *EvaluationContext) bool {
+	reference, ok := node.(*PlainReferenceNode)
+	if ok && !context.IsVarDefined(reference.Id) {
+		return false
+	}
+
 	return node.IsTrue(context)
 }
 
@@ -81,8 +87,8 @@ func expressionIntValue(node ExpressionNode, context *EvaluationContext) utils.N
 		return utils.NilNumber()
 	}
 
-	number, ok := value.(utils.Number)
-	if ok {
+	number, ok := utils.AsNumber(value)
+	if ok && number.IsInteger() {
 		return number
 	}
 
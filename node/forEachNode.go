@@ -12,8 +12,7 @@
 package node
 
 import (
-	"errors"
-	"reflect"
+	"fmt"
 	"strings"
 )
 
@@ -21,16 +20,22 @@ import (
  * A node in the parse tree representing a {@code #foreach} construct. While evaluating
  * {@code #foreach ($x in $things)}, {$code $x} will be set to each element of {@code $things} in
  * turn. Once the loop completes, {@code $x} will go back to whatever value it had before, which
- * might be undefined. During loop execution, the variable {@code $foreach} is also defined.
- * Velocity defines a number of properties in this variable, but here we only support
- * {@code $foreach.hasNext} and {@code $foreach.index}.
+ * might be undefined. During loop execution, the variable {@code $foreach} is also defined,
+ * as a `ForEachScope` with the properties {@code index}, {@code count}, {@code hasNext},
+ * {@code first}, {@code last} and {@code parent}. For templates written for older versions of
+ * Velocity, {@code $velocityCount} and {@code $velocityHasNext} are defined as well.
+ *
+ * <p>The optional else body, from {@code #foreach (...) body #else else-body #end}, is
+ * rendered instead of the body when the collection is null or empty.
  */
 type ForEachNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Variable     string
 	Collection   ExpressionNode
 	Body         Node
+	ElseBody     Node
 	Type         string
 }
 
@@ -54,31 +59,73 @@ func (node *ForEachNode) Render(context *EvaluationContext, output *strings.Buil
 	collectionValue := node.Collection.Evaluate(context)
 
 	if collectionValue == nil {
+		node.renderElse(context, output)
+		return
+	}
+
+	iterator, ok := newIterator(collectionValue)
+	if !ok {
+		message := fmt.Sprintf("Value of type %T is not iterable in #foreach", collectionValue)
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
+	}
+
+	if !iterator.hasNext() {
+		node.renderElse(context, output)
 		return
 	}
 
-	typeOf := reflect.TypeOf(collectionValue)
-	kind := typeOf.Kind()
+	var parent *ForEachScope
+	if context.IsVarDefined("foreach") {
+		parent, _ = context.GetVar("foreach").(*ForEachScope)
+	}
 
-	switch kind {
-	case reflect.Slice:
-	case reflect.Array:
-	case reflect.Map:
-		break
+	scope := NewForEachScope(parent)
 
-	default:
-		panic(errors.New("Value is not iterable"))
+	undos := []func(){
+		context.SetVar(node.Variable, nil),
+		context.SetVar("foreach", scope),
+		context.SetVar("velocityCount", 0),
+		context.SetVar("velocityHasNext", false),
 	}
+	defer func() {
+		for index := len(undos) - 1; index >= 0; index-- {
+			undos[index]()
+		}
+	}()
+
+	context.breakableDepth++
+	defer func() {
+		context.breakableDepth--
+	}()
 
+	defer recoverBreak(scope)
+
+	for index := 0; iterator.hasNext(); index++ {
+		context.SetVar(node.Variable, iterator.next())
+
+		scope.update(index, iterator.hasNext())
+		context.SetVar("velocityCount", scope.Count)
+		context.SetVar("velocityHasNext", scope.HasNext)
+
+		node.Body.Render(context, output)
+	}
+}
+
+func (node *ForEachNode) renderElse(context *EvaluationContext, output *strings.Builder) {
+	if node.ElseBody != nil {
+		node.ElseBody.Render(context, output)
+	}
 }
 
-func NewForEachNode(resourceName string, lineNumber uint, id string, collection ExpressionNode, body Node) *ForEachNode {
+func NewForEachNode(resourceName string, lineNumber uint, columnNumber uint, id string, collection ExpressionNode, body Node, elseBody Node) *ForEachNode {
 	return &ForEachNode{
 		ResourceName: resourceName,
 		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
 		Variable:     id,
 		Collection:   collection,
 		Body:         body,
+		ElseBody:     elseBody,
 		Type:         "ForEach",
 	}
 }
@@ -0,0 +1,71 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+/**
+ * The value of {@code $foreach} while the body of a {@code #foreach} is evaluated. It
+ * describes the current iteration, and gives access to the scope of the enclosing
+ * {@code #foreach} through {@code $foreach.parent}.
+ */
+type ForEachScope struct {
+	Index   int
+	Count   int
+	HasNext bool
+	First   bool
+	Last    bool
+	Parent  *ForEachScope
+}
+
+func (scope *ForEachScope) GetProperty(name string) (interface{}, bool) {
+	switch name {
+	case "index":
+		return scope.Index, true
+
+	case "count":
+		return scope.Count, true
+
+	case "hasNext":
+		return scope.HasNext, true
+
+	case "first":
+		return scope.First, true
+
+	case "last":
+		return scope.Last, true
+
+	case "parent":
+		if scope.Parent == nil {
+			return nil, true
+		}
+
+		return scope.Parent, true
+	}
+
+	return nil, false
+}
+
+/**
+ * Moves the scope to the iteration with the given 0-based index.
+ */
+func (scope *ForEachScope) update(index int, hasNext bool) {
+	scope.Index = index
+	scope.Count = index + 1
+	scope.HasNext = hasNext
+	scope.First = index == 0
+	scope.Last = !hasNext
+}
+
+func NewForEachScope(parent *ForEachScope) *ForEachScope {
+	return &ForEachScope{
+		Parent: parent,
+	}
+}
@@ -23,6 +23,7 @@ import "strings"
 type IfNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Condition    ExpressionNode
 	TruePart     Node
 	FalsePart    Node
@@ -46,13 +47,18 @@ func (node *IfNode) MarkDirectiveNode() {
 }
 
 func (node *IfNode) Render(context *EvaluationContext, output *strings.Builder) {
-
+	if isExpressionDefinedAndTrue(node.Condition, context) {
+		node.TruePart.Render(context, output)
+	} else {
+		node.FalsePart.Render(context, output)
+	}
 }
 
-func NewIfNode(resourceName string, lineNumber uint, condition ExpressionNode, truePart Node, falsePart Node) *IfNode {
+func NewIfNode(resourceName string, lineNumber uint, columnNumber uint, condition ExpressionNode, truePart Node, falsePart Node) *IfNode {
 	return &IfNode{
 		ResourceName: resourceName,
 		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
 		Condition:    condition,
 		TruePart:     truePart,
 		FalsePart:    falsePart,
@@ -0,0 +1,88 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"io"
+	"strings"
+
+	"sangupta.com/velocity/utils"
+)
+
+/**
+ * A node in the parse tree representing an {@code #include} directive, like
+ * {@code #include ("footer.txt", $other)}. Each named resource is copied to the output as it
+ * is, without being interpreted as a template. The arguments are evaluated when the directive
+ * is, so they can be variables.
+ */
+type IncludeNode struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Arguments    []ExpressionNode
+	Type         string
+}
+
+func (node *IncludeNode) String() string {
+	return ""
+}
+
+func (node *IncludeNode) IsWhitespace() bool {
+	return false
+}
+
+func (node *IncludeNode) IsHorizontalWhitespace() bool {
+	return false
+}
+
+func (node *IncludeNode) MarkDirectiveNode() {
+
+}
+
+func (node *IncludeNode) Render(context *EvaluationContext, output *strings.Builder) {
+	for _, argument := range node.Arguments {
+		value := argument.Evaluate(context)
+		if value == nil {
+			panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "#include argument is null", nil))
+		}
+
+		node.include(context, output, utils.AsString(value))
+	}
+}
+
+func (node *IncludeNode) include(context *EvaluationContext, output *strings.Builder, name string) {
+	if context.Runtime == nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "cannot #include "+name+" without a runtime", nil))
+	}
+
+	reader, err := context.Runtime.OpenResource(name)
+	if err != nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "cannot #include "+name, err))
+	}
+
+	defer reader.Close()
+
+	_, err = io.Copy(output, reader)
+	if err != nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "cannot #include "+name, err))
+	}
+}
+
+func NewIncludeNode(resourceName string, lineNumber uint, columnNumber uint, arguments []ExpressionNode) *IncludeNode {
+	return &IncludeNode{
+		ResourceName: resourceName,
+		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
+		Arguments:    arguments,
+		Type:         "Include",
+	}
+}
@@ -11,7 +11,10 @@
 
 package node
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 /**
  * A node in the parse tree that is an indexing of a reference, like {@code $x[0]} or
@@ -21,6 +24,7 @@ import "strings"
 type IndexReferenceNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Lhs          ReferenceNode
 	Index        ExpressionNode
 	Silent       bool
@@ -35,6 +39,10 @@ func (cons *IndexReferenceNode) GetLineNumber() uint {
 	return cons.LineNumber
 }
 
+func (cons *IndexReferenceNode) GetColumnNumber() uint {
+	return cons.ColumnNumber
+}
+
 func (node *IndexReferenceNode) String() string {
 	return ""
 }
@@ -63,14 +71,42 @@ func (node *IndexReferenceNode) IsTrue(context *EvaluationContext) bool {
 	return isExpressionTrue(node, context)
 }
 
+/**
+ * Evaluates the indexing as a call of the {@code get} method of the value of `Lhs`, as
+ * described for `invokeMethod`, which for a Go slice is array indexing and for a Go map
+ * finds the value of the key, nil if it is not present.
+ */
 func (node *IndexReferenceNode) Evaluate(context *EvaluationContext) interface{} {
-	return nil
+	lhsValue := node.Lhs.Evaluate(context)
+	if isNil(lhsValue) {
+		if node.Silent {
+			return nil
+		}
+
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "Cannot index null value", nil))
+	}
+
+	index := node.Index.Evaluate(context)
+
+	value, found, err := invokeMethod(lhsValue, "get", []interface{}{index})
+	if err != nil {
+		message := fmt.Sprintf("Cannot index a %T", lhsValue)
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, err))
+	}
+
+	if !found {
+		message := fmt.Sprintf("Cannot index a %T", lhsValue)
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
+	}
+
+	return value
 }
 
 func NewIndexReferenceNode(lhs ReferenceNode, index ExpressionNode, silent bool) *IndexReferenceNode {
 	return &IndexReferenceNode{
 		ResourceName: lhs.GetResourceName(),
 		LineNumber:   lhs.GetLineNumber(),
+		ColumnNumber: lhs.GetColumnNumber(),
 		Lhs:          lhs,
 		Index:        index,
 		Silent:       silent,
@@ -0,0 +1,140 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * The value of a range literal like {@code [1..$n]}: the integers from `First` to `Last`,
+ * both included, in ascending order if `First` is the smaller and descending order
+ * otherwise. The integers are not held anywhere, so that {@code #foreach} can iterate
+ * over a large range without allocating them.
+ */
+type IntRange struct {
+	First int64
+	Last  int64
+}
+
+/**
+ * Returns the number of integers in the range, which `RangeLiteralNode` makes sure
+ * fits in an {@code int}.
+ */
+func (intRange *IntRange) Size() int {
+	return int(intRange.span()) + 1
+}
+
+func (intRange *IntRange) IsEmpty() bool {
+	return false
+}
+
+/**
+ * Returns the integer at the given 0-based position in the range.
+ */
+func (intRange *IntRange) Get(index int) (int64, error) {
+	if index < 0 || index >= intRange.Size() {
+		return 0, fmt.Errorf("index %d out of bounds for length %d", index, intRange.Size())
+	}
+
+	return intRange.First + int64(index)*intRange.step(), nil
+}
+
+/**
+ * True if the given integer is in the range.
+ */
+func (intRange *IntRange) Contains(value int64) bool {
+	if intRange.First <= intRange.Last {
+		return value >= intRange.First && value <= intRange.Last
+	}
+
+	return value <= intRange.First && value >= intRange.Last
+}
+
+/**
+ * Renders the range as Velocity does, like {@code [1, 2, 3]}.
+ */
+func (intRange *IntRange) String() string {
+	builder := strings.Builder{}
+	builder.WriteRune('[')
+
+	iterator := intRange.iterator()
+	for iterator.hasNext() {
+		builder.WriteString(fmt.Sprint(iterator.next()))
+		if iterator.hasNext() {
+			builder.WriteString(", ")
+		}
+	}
+
+	builder.WriteRune(']')
+	return builder.String()
+}
+
+/**
+ * Returns the distance between the bounds, which may not fit in an {@code int64}.
+ */
+func (intRange *IntRange) span() uint64 {
+	if intRange.First <= intRange.Last {
+		return uint64(intRange.Last) - uint64(intRange.First)
+	}
+
+	return uint64(intRange.First) - uint64(intRange.Last)
+}
+
+func (intRange *IntRange) step() int64 {
+	if intRange.First <= intRange.Last {
+		return 1
+	}
+
+	return -1
+}
+
+func (intRange *IntRange) iterator() *rangeIterator {
+	return &rangeIterator{
+		current: intRange.First,
+		last:    intRange.Last,
+		step:    intRange.step(),
+	}
+}
+
+/**
+ * Iterates over the integers of an `IntRange`, computing each as it is needed.
+ */
+type rangeIterator struct {
+	current int64
+	last    int64
+	step    int64
+	done    bool
+}
+
+func (iterator *rangeIterator) hasNext() bool {
+	return !iterator.done
+}
+
+func (iterator *rangeIterator) next() interface{} {
+	value := iterator.current
+	if value == iterator.last {
+		iterator.done = true
+	} else {
+		iterator.current += iterator.step
+	}
+
+	return value
+}
+
+func NewIntRange(first int64, last int64) *IntRange {
+	return &IntRange{
+		First: first,
+		Last:  last,
+	}
+}
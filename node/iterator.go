@@ -0,0 +1,135 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"reflect"
+
+	"sangupta.com/velocity/utils"
+)
+
+/**
+ * Iterates over the elements of a value used as the collection of a {@code #foreach}.
+ */
+type iterator interface {
+	hasNext() bool
+	next() interface{}
+}
+
+/**
+ * Iterates over the elements of a slice or array, or the values of a map taken in the
+ * order of their keys.
+ */
+type indexIterator struct {
+	length int
+	index  int
+	get    func(index int) reflect.Value
+}
+
+func (iterator *indexIterator) hasNext() bool {
+	return iterator.index < iterator.length
+}
+
+func (iterator *indexIterator) next() interface{} {
+	value := iterator.get(iterator.index)
+	iterator.index++
+
+	return value.Interface()
+}
+
+/**
+ * Iterates over a list of values, such as those of an `OrderedMap`.
+ */
+type valuesIterator struct {
+	values []interface{}
+	index  int
+}
+
+func (iterator *valuesIterator) hasNext() bool {
+	return iterator.index < len(iterator.values)
+}
+
+func (iterator *valuesIterator) next() interface{} {
+	value := iterator.values[iterator.index]
+	iterator.index++
+
+	return value
+}
+
+/**
+ * Iterates over the characters of a string, each returned as a string itself.
+ */
+type stringIterator struct {
+	chars []rune
+	index int
+}
+
+func (iterator *stringIterator) hasNext() bool {
+	return iterator.index < len(iterator.chars)
+}
+
+func (iterator *stringIterator) next() interface{} {
+	char := iterator.chars[iterator.index]
+	iterator.index++
+
+	return string(char)
+}
+
+/**
+ * Returns an iterator over the given value, or false if the value cannot be iterated.
+ * Slices and arrays are iterated in order, maps over their values in the order of their
+ * keys, and strings over their characters. Pointers and interfaces are followed. An
+ * `OrderedMap` is iterated over its values in the order of its keys, and an `IntRange`
+ * over its integers.
+ */
+func newIterator(value interface{}) (iterator, bool) {
+	switch collection := value.(type) {
+	case *OrderedMap:
+		return &valuesIterator{values: collection.Values()}, true
+
+	case *IntRange:
+		return collection.iterator(), true
+	}
+
+	reflected := reflect.ValueOf(value)
+	for reflected.Kind() == reflect.Ptr || reflected.Kind() == reflect.Interface {
+		if reflected.IsNil() {
+			return &indexIterator{}, true
+		}
+
+		reflected = reflected.Elem()
+	}
+
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array:
+		return &indexIterator{
+			length: reflected.Len(),
+			get:    reflected.Index,
+		}, true
+
+	case reflect.Map:
+		keys := utils.SortedMapKeys(reflected)
+		return &indexIterator{
+			length: len(keys),
+			get: func(index int) reflect.Value {
+				return reflected.MapIndex(keys[index])
+			},
+		}, true
+
+	case reflect.String:
+		return &stringIterator{
+			chars: []rune(reflected.String()),
+		}, true
+	}
+
+	return nil, false
+}
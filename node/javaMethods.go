@@ -0,0 +1,576 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"sangupta.com/velocity/utils"
+)
+
+/**
+ * A method of a Java type, like {@code String.substring}, implemented for the Go values
+ * that stand in for that type, so that templates written for Velocity can call it.
+ */
+type javaMethod struct {
+	minArguments int
+	maxArguments int
+	call         func(receiver reflect.Value, arguments []interface{}) (interface{}, error)
+}
+
+/**
+ * The methods of {@code java.lang.String}, available on Go strings. Indexes and lengths
+ * count characters rather than bytes.
+ */
+var javaStringMethods = map[string]javaMethod{
+	"length": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return utf8.RuneCountInString(receiver.String()), nil
+	}},
+	"isEmpty": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return receiver.Len() == 0, nil
+	}},
+	"charAt": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		chars := []rune(receiver.String())
+		index, err := indexArgument(arguments, 0, len(chars)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(chars[index]), nil
+	}},
+	"substring": {1, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		chars := []rune(receiver.String())
+		begin, end, err := rangeArguments(arguments, len(chars))
+		if err != nil {
+			return nil, err
+		}
+
+		return string(chars[begin:end]), nil
+	}},
+	"indexOf": {1, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		chars := []rune(receiver.String())
+		from := 0
+		if len(arguments) == 2 {
+			var err error
+			from, err = intArgument(arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+
+			if from < 0 {
+				from = 0
+			}
+		}
+
+		if from > len(chars) {
+			return -1, nil
+		}
+
+		index := strings.Index(string(chars[from:]), utils.AsString(arguments[0]))
+		if index < 0 {
+			return -1, nil
+		}
+
+		return from + utf8.RuneCountInString(string(chars[from:])[:index]), nil
+	}},
+	"lastIndexOf": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		str := receiver.String()
+		index := strings.LastIndex(str, utils.AsString(arguments[0]))
+		if index < 0 {
+			return -1, nil
+		}
+
+		return utf8.RuneCountInString(str[:index]), nil
+	}},
+	"contains": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return strings.Contains(receiver.String(), utils.AsString(arguments[0])), nil
+	}},
+	"startsWith": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return strings.HasPrefix(receiver.String(), utils.AsString(arguments[0])), nil
+	}},
+	"endsWith": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return strings.HasSuffix(receiver.String(), utils.AsString(arguments[0])), nil
+	}},
+	"equalsIgnoreCase": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		other, ok := arguments[0].(string)
+		return ok && strings.EqualFold(receiver.String(), other), nil
+	}},
+	"compareTo": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return strings.Compare(receiver.String(), utils.AsString(arguments[0])), nil
+	}},
+	"concat": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return receiver.String() + utils.AsString(arguments[0]), nil
+	}},
+	"toUpperCase": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return strings.ToUpper(receiver.String()), nil
+	}},
+	"toLowerCase": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return strings.ToLower(receiver.String()), nil
+	}},
+	"trim": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return strings.TrimSpace(receiver.String()), nil
+	}},
+	"replace": {2, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return strings.ReplaceAll(receiver.String(), utils.AsString(arguments[0]), utils.AsString(arguments[1])), nil
+	}},
+	"replaceAll": {2, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		pattern, err := regexp.Compile(utils.AsString(arguments[0]))
+		if err != nil {
+			return nil, err
+		}
+
+		return pattern.ReplaceAllString(receiver.String(), javaReplacement(utils.AsString(arguments[1]))), nil
+	}},
+	"replaceFirst": {2, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		pattern, err := regexp.Compile(utils.AsString(arguments[0]))
+		if err != nil {
+			return nil, err
+		}
+
+		str := receiver.String()
+		match := pattern.FindStringSubmatchIndex(str)
+		if match == nil {
+			return str, nil
+		}
+
+		replaced := pattern.ExpandString(nil, javaReplacement(utils.AsString(arguments[1])), str, match)
+		return str[:match[0]] + string(replaced) + str[match[1]:], nil
+	}},
+	"matches": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		pattern, err := regexp.Compile(`^(?:` + utils.AsString(arguments[0]) + `)$`)
+		if err != nil {
+			return nil, err
+		}
+
+		return pattern.MatchString(receiver.String()), nil
+	}},
+	"split": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		pattern, err := regexp.Compile(utils.AsString(arguments[0]))
+		if err != nil {
+			return nil, err
+		}
+
+		// as in Java, trailing empty strings are not included
+		parts := pattern.Split(receiver.String(), -1)
+		for len(parts) > 1 && parts[len(parts)-1] == "" {
+			parts = parts[:len(parts)-1]
+		}
+
+		return parts, nil
+	}},
+}
+
+/**
+ * The methods of {@code java.util.List}, available on Go slices and arrays.
+ */
+var javaListMethods = map[string]javaMethod{
+	"size": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return receiver.Len(), nil
+	}},
+	"isEmpty": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return receiver.Len() == 0, nil
+	}},
+	"get": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		index, err := indexArgument(arguments, 0, receiver.Len()-1)
+		if err != nil {
+			return nil, err
+		}
+
+		return receiver.Index(index).Interface(), nil
+	}},
+	"contains": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return indexOfElement(receiver, arguments[0], false) >= 0, nil
+	}},
+	"indexOf": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return indexOfElement(receiver, arguments[0], false), nil
+	}},
+	"lastIndexOf": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return indexOfElement(receiver, arguments[0], true), nil
+	}},
+	"add": {1, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		if receiver.Kind() != reflect.Slice || !receiver.CanSet() {
+			return nil, fmt.Errorf("cannot add to a %s that is not held by a pointer", receiver.Type())
+		}
+
+		index := receiver.Len()
+		if len(arguments) == 2 {
+			var err error
+			index, err = indexArgument(arguments, 0, receiver.Len())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		element, err := elementArgument(receiver, arguments[len(arguments)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		length := receiver.Len()
+		receiver.Set(reflect.Append(receiver, element))
+		reflect.Copy(receiver.Slice(index+1, length+1), receiver.Slice(index, length))
+		receiver.Index(index).Set(element)
+
+		// as in Java, add(element) returns true and add(index, element) nothing
+		if len(arguments) == 2 {
+			return nil, nil
+		}
+
+		return true, nil
+	}},
+	"set": {2, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		index, err := indexArgument(arguments, 0, receiver.Len()-1)
+		if err != nil {
+			return nil, err
+		}
+
+		target := receiver.Index(index)
+		if !target.CanSet() {
+			return nil, fmt.Errorf("cannot set an element of a %s that is not held by a pointer", receiver.Type())
+		}
+
+		element, err := elementArgument(receiver, arguments[1])
+		if err != nil {
+			return nil, err
+		}
+
+		previous := target.Interface()
+		target.Set(element)
+
+		return previous, nil
+	}},
+	"subList": {2, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		begin, end, err := rangeArguments(arguments, receiver.Len())
+		if err != nil {
+			return nil, err
+		}
+
+		return receiver.Slice(begin, end).Interface(), nil
+	}},
+}
+
+/**
+ * The methods of {@code java.util.Map}, available on Go maps. Keys, values and entries
+ * are returned in the order of the keys, as {@code #foreach} iterates over a map.
+ */
+var javaMapMethods = map[string]javaMethod{
+	"size": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return receiver.Len(), nil
+	}},
+	"isEmpty": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return receiver.Len() == 0, nil
+	}},
+	"get": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		value, _ := mapEntry(receiver, arguments[0])
+		return value, nil
+	}},
+	"put": {2, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		if receiver.IsNil() {
+			return nil, fmt.Errorf("cannot put into a nil %s", receiver.Type())
+		}
+
+		key, ok := convertArgument(arguments[0], receiver.Type().Key())
+		if !ok || !isComparable(key) {
+			return nil, fmt.Errorf("a %T cannot be a key of a %s", arguments[0], receiver.Type())
+		}
+
+		value, ok := convertArgument(arguments[1], receiver.Type().Elem())
+		if !ok {
+			return nil, fmt.Errorf("a %T cannot be a value of a %s", arguments[1], receiver.Type())
+		}
+
+		previous, _ := mapEntry(receiver, arguments[0])
+		receiver.SetMapIndex(key, value)
+
+		return previous, nil
+	}},
+	"getOrDefault": {2, 2, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		value, found := mapEntry(receiver, arguments[0])
+		if !found {
+			return arguments[1], nil
+		}
+
+		return value, nil
+	}},
+	"containsKey": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		_, found := mapEntry(receiver, arguments[0])
+		return found, nil
+	}},
+	"containsValue": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		for _, key := range receiver.MapKeys() {
+			if javaEquals(receiver.MapIndex(key).Interface(), arguments[0]) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}},
+	"keySet": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		keys := utils.SortedMapKeys(receiver)
+		result := make([]interface{}, len(keys))
+		for index, key := range keys {
+			result[index] = key.Interface()
+		}
+
+		return result, nil
+	}},
+	"values": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		keys := utils.SortedMapKeys(receiver)
+		result := make([]interface{}, len(keys))
+		for index, key := range keys {
+			result[index] = receiver.MapIndex(key).Interface()
+		}
+
+		return result, nil
+	}},
+	"entrySet": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		keys := utils.SortedMapKeys(receiver)
+		result := make([]*MapEntry, len(keys))
+		for index, key := range keys {
+			result[index] = &MapEntry{
+				Key:   key.Interface(),
+				Value: receiver.MapIndex(key).Interface(),
+			}
+		}
+
+		return result, nil
+	}},
+}
+
+/**
+ * The methods of {@code java.lang.Object}, available on every value.
+ */
+var javaObjectMethods = map[string]javaMethod{
+	"toString": {0, 0, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return utils.AsString(receiver.Interface()), nil
+	}},
+	"equals": {1, 1, func(receiver reflect.Value, arguments []interface{}) (interface{}, error) {
+		return javaEquals(receiver.Interface(), arguments[0]), nil
+	}},
+}
+
+/**
+ * An entry of a map as returned by {@code $map.entrySet()}, whose key and value are
+ * {@code $entry.key} and {@code $entry.value}.
+ */
+type MapEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+/**
+ * Finds the Java method with the given name for a value that has no Go method of that
+ * name, following pointers and interfaces. Also returns the value the method applies to.
+ */
+func findJavaMethod(value interface{}, name string) (javaMethod, reflect.Value, bool) {
+	reflected := reflect.ValueOf(value)
+	for reflected.Kind() == reflect.Ptr || reflected.Kind() == reflect.Interface {
+		if reflected.IsNil() {
+			return javaMethod{}, reflect.Value{}, false
+		}
+
+		reflected = reflected.Elem()
+	}
+
+	var methods map[string]javaMethod
+	switch reflected.Kind() {
+	case reflect.String:
+		methods = javaStringMethods
+
+	case reflect.Slice, reflect.Array:
+		methods = javaListMethods
+
+	case reflect.Map:
+		methods = javaMapMethods
+	}
+
+	method, found := methods[name]
+	if !found {
+		method, found = javaObjectMethods[name]
+	}
+
+	return method, reflected, found
+}
+
+/**
+ * Calls a Java method after checking the number of arguments it is given.
+ */
+func invokeJavaMethod(method javaMethod, receiver reflect.Value, name string, arguments []interface{}) (interface{}, error) {
+	if len(arguments) < method.minArguments || len(arguments) > method.maxArguments {
+		if method.minArguments == method.maxArguments {
+			return nil, fmt.Errorf("%s expects %d arguments, got %d", name, method.minArguments, len(arguments))
+		}
+
+		return nil, fmt.Errorf("%s expects %d to %d arguments, got %d", name, method.minArguments, method.maxArguments, len(arguments))
+	}
+
+	return method.call(receiver, arguments)
+}
+
+func intArgument(arguments []interface{}, position int) (int, error) {
+	converted, ok := convertArgument(arguments[position], reflect.TypeOf(0))
+	if !ok {
+		return 0, fmt.Errorf("argument %d must be an integer, got %T", position+1, arguments[position])
+	}
+
+	return int(converted.Int()), nil
+}
+
+/**
+ * Returns the integer argument at the given position, which must be an index between
+ * 0 and {@code last}, the way Java reports an {@code IndexOutOfBoundsException}.
+ */
+func indexArgument(arguments []interface{}, position int, last int) (int, error) {
+	index, err := intArgument(arguments, position)
+	if err != nil {
+		return 0, err
+	}
+
+	if index < 0 || index > last {
+		return 0, fmt.Errorf("index %d out of bounds for length %d", index, last+1)
+	}
+
+	return index, nil
+}
+
+/**
+ * Returns the begin and end indexes given as arguments to methods like
+ * {@code substring}, where the end defaults to the length.
+ */
+func rangeArguments(arguments []interface{}, length int) (int, int, error) {
+	begin, err := intArgument(arguments, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end := length
+	if len(arguments) > 1 {
+		end, err = intArgument(arguments, 1)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if begin < 0 || end > length || begin > end {
+		return 0, 0, fmt.Errorf("begin %d, end %d, length %d", begin, end, length)
+	}
+
+	return begin, end, nil
+}
+
+/**
+ * Converts a value to the element type of a list, as for a method argument.
+ */
+func elementArgument(list reflect.Value, value interface{}) (reflect.Value, error) {
+	element, ok := convertArgument(value, list.Type().Elem())
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("a %T cannot be an element of a %s", value, list.Type())
+	}
+
+	return element, nil
+}
+
+func indexOfElement(list reflect.Value, element interface{}, last bool) int {
+	found := -1
+	for index := 0; index < list.Len(); index++ {
+		if javaEquals(list.Index(index).Interface(), element) {
+			found = index
+			if !last {
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+/**
+ * Returns the value for a key of a map, converting the key to the key type of the map.
+ */
+func mapEntry(reflected reflect.Value, key interface{}) (interface{}, bool) {
+	converted, ok := convertArgument(key, reflected.Type().Key())
+	if !ok || !isComparable(converted) {
+		return nil, false
+	}
+
+	entry := reflected.MapIndex(converted)
+	if !entry.IsValid() {
+		return nil, false
+	}
+
+	return entry.Interface(), true
+}
+
+/**
+ * True if two values are equal in the sense of Java's {@code equals}: numbers are equal
+ * if they have the same value whatever their Go types, compared as described for
+ * `utils.Number`, and other values if they are deeply equal. Either way the result does
+ * not depend on the order of the values.
+ */
+func javaEquals(left interface{}, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+
+	leftNumber, isLeftNumber := utils.AsNumber(left)
+	rightNumber, isRightNumber := utils.AsNumber(right)
+	if isLeftNumber && isRightNumber {
+		return leftNumber.Equals(rightNumber)
+	}
+
+	return reflect.DeepEqual(left, right)
+}
+
+/**
+ * Converts the replacement string of Java's {@code replaceAll}, where {@code $1} refers to
+ * a group and {@code \$} is a literal dollar, to the syntax of `regexp.Expand`.
+ */
+func javaReplacement(replacement string) string {
+	var builder strings.Builder
+	chars := []rune(replacement)
+	for index := 0; index < len(chars); index++ {
+		char := chars[index]
+		switch {
+		case char == '\\' && index+1 < len(chars):
+			index++
+			if chars[index] == '$' {
+				builder.WriteString("$$")
+			} else {
+				builder.WriteRune(chars[index])
+			}
+
+		case char == '$':
+			start := index + 1
+			end := start
+			for end < len(chars) && utils.IsAsciiDigit(chars[end]) {
+				end++
+			}
+
+			if end == start {
+				builder.WriteString("$$")
+				break
+			}
+
+			builder.WriteString("${" + string(chars[start:end]) + "}")
+			index = end - 1
+
+		default:
+			builder.WriteRune(char)
+		}
+	}
+
+	return builder.String()
+}
@@ -13,9 +13,13 @@ package node
 
 import "strings"
 
+/**
+ * A node in the parse tree representing a list literal, like {@code ["a", $b, [1, 2]]}.
+ */
 type ListLiteralNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Elements     []ExpressionNode
 	Type         string
 }
@@ -28,6 +32,10 @@ func (cons *ListLiteralNode) GetLineNumber() uint {
 	return cons.LineNumber
 }
 
+func (cons *ListLiteralNode) GetColumnNumber() uint {
+	return cons.ColumnNumber
+}
+
 func (node *ListLiteralNode) String() string {
 	return ""
 }
@@ -52,14 +60,25 @@ func (node *ListLiteralNode) IsTrue(context *EvaluationContext) bool {
 	return isExpressionTrue(node, context)
 }
 
+/**
+ * Evaluates the elements into a new {@code []interface{}}, so that a template changing
+ * the list, as with {@code $list.add($x)}, does not change the literal. How a list that
+ * is not held by a pointer grows is described for `MethodReferenceNode.Evaluate`.
+ */
 func (node *ListLiteralNode) Evaluate(context *EvaluationContext) interface{} {
-	return nil
+	elements := make([]interface{}, len(node.Elements))
+	for index, element := range node.Elements {
+		elements[index] = element.Evaluate(context)
+	}
+
+	return elements
 }
 
-func NewListLiteralNode(name string, line uint, elements []ExpressionNode) *ListLiteralNode {
+func NewListLiteralNode(name string, line uint, column uint, elements []ExpressionNode) *ListLiteralNode {
 	return &ListLiteralNode{
 		ResourceName: name,
 		LineNumber:   line,
+		ColumnNumber: column,
 		Elements:     elements,
 		Type:         "ListLiteral",
 	}
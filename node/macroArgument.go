@@ -0,0 +1,60 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+/**
+ * A thunk bound to a macro parameter for the duration of a macro call. Velocity macros
+ * are call-by-name, so the argument expression is evaluated each time the parameter is
+ * referenced rather than once at the call.
+ *
+ * <p>The expression must be evaluated as the caller would see it. Since the macro's own
+ * parameters may hide variables of the same name at the call site, as in
+ * {@code #mymacro ($x)} for {@code #macro (mymacro $x)}, those variables are temporarily
+ * given back their caller values while the expression is evaluated.
+ */
+type MacroArgument struct {
+	Expression     ExpressionNode
+	context        *EvaluationContext
+	parameterNames []string
+	callerValues   map[string]interface{}
+}
+
+func (argument *MacroArgument) Evaluate() interface{} {
+	context := argument.context
+
+	undos := make([]func(), 0, len(argument.parameterNames))
+	defer func() {
+		for index := len(undos) - 1; index >= 0; index-- {
+			undos[index]()
+		}
+	}()
+
+	for _, name := range argument.parameterNames {
+		value, isDefined := argument.callerValues[name]
+		if isDefined {
+			undos = append(undos, context.SetVar(name, value))
+		} else {
+			undos = append(undos, context.UnsetVar(name))
+		}
+	}
+
+	return argument.Expression.Evaluate(context)
+}
+
+func NewMacroArgument(expression ExpressionNode, context *EvaluationContext, parameterNames []string, callerValues map[string]interface{}) *MacroArgument {
+	return &MacroArgument{
+		Expression:     expression,
+		context:        context,
+		parameterNames: parameterNames,
+		callerValues:   callerValues,
+	}
+}
@@ -11,7 +11,21 @@
 
 package node
 
-import "strings"
+import (
+	"errors"
+	"strings"
+)
+
+/**
+ * The definition of a macro as seen by the calls to it. Calls are bound to their
+ * definition once the whole template has been parsed, which is what allows a macro
+ * to be called before it is defined.
+ */
+type MacroDefinition interface {
+	GetName() string
+	GetParameterNames() []string
+	GetBody() Node
+}
 
 /**
  * A node in the parse tree representing a macro call. If the template contains a definition like
@@ -21,13 +35,16 @@ import "strings"
  *
  * <p>Evaluating a macro involves temporarily setting the parameter variables ({@code $x $y} in
  * the example) to thunks representing the argument expressions, evaluating the macro body, and
- * restoring any previous values that the parameter variables had.
+ * restoring any previous values that the parameter variables had. While the body is evaluated,
+ * {@code $macro} is a `MacroScope` that {@code #break} can use to leave the macro.
  */
 type MacroCallNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Name         string
 	Thunks       []ExpressionNode
+	Macro        MacroDefinition `json:"-"`
 	Type         string
 }
 
@@ -47,14 +64,64 @@ func (node *MacroCallNode) MarkDirectiveNode() {
 
 }
 
+/**
+ * Binds this call to the definition of the macro it calls.
+ */
+func (node *MacroCallNode) SetMacro(macro MacroDefinition) {
+	node.Macro = macro
+}
+
 func (node *MacroCallNode) Render(context *EvaluationContext, output *strings.Builder) {
+	if node.Macro == nil {
+		panic(errors.New("macro call has not been bound to a definition: #" + node.Name))
+	}
+
+	parameterNames := node.Macro.GetParameterNames()
+
+	// the values the parameter names have at the call site, which is what
+	// they must have again whenever one of the thunks is evaluated
+	callerValues := make(map[string]interface{}, len(parameterNames))
+	for _, name := range parameterNames {
+		if context.IsVarDefined(name) {
+			callerValues[name] = context.GetVar(name)
+		}
+	}
+
+	undos := make([]func(), 0, len(parameterNames))
+	defer func() {
+		for index := len(undos) - 1; index >= 0; index-- {
+			undos[index]()
+		}
+	}()
+
+	for index, name := range parameterNames {
+		argument := NewMacroArgument(node.Thunks[index], context, parameterNames, callerValues)
+		undos = append(undos, context.SetVar(name, argument))
+	}
+
+	var parent *MacroScope
+	if context.IsVarDefined("macro") {
+		parent, _ = context.GetVar("macro").(*MacroScope)
+	}
+
+	scope := NewMacroScope(node.Name, parent)
+	undos = append(undos, context.SetVar("macro", scope))
+
+	context.breakableDepth++
+	defer func() {
+		context.breakableDepth--
+	}()
+
+	defer recoverBreak(scope)
 
+	node.Macro.GetBody().Render(context, output)
 }
 
-func NewMacroCallNode(resourceName string, lineNumber uint, name string, thunks []ExpressionNode) *MacroCallNode {
+func NewMacroCallNode(resourceName string, lineNumber uint, columnNumber uint, name string, thunks []ExpressionNode) *MacroCallNode {
 	return &MacroCallNode{
 		ResourceName: resourceName,
 		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
 		Name:         name,
 		Thunks:       thunks,
 		Type:         "MacroCall",
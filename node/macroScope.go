@@ -0,0 +1,45 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+/**
+ * The value of {@code $macro} while the body of a macro is evaluated. It can be given to
+ * {@code #break} to leave the macro, and gives access to the scope of the macro call that
+ * encloses this one through {@code $macro.parent}.
+ */
+type MacroScope struct {
+	Name   string
+	Parent *MacroScope
+}
+
+func (scope *MacroScope) GetProperty(name string) (interface{}, bool) {
+	switch name {
+	case "name":
+		return scope.Name, true
+
+	case "parent":
+		if scope.Parent == nil {
+			return nil, true
+		}
+
+		return scope.Parent, true
+	}
+
+	return nil, false
+}
+
+func NewMacroScope(name string, parent *MacroScope) *MacroScope {
+	return &MacroScope{
+		Name:   name,
+		Parent: parent,
+	}
+}
@@ -0,0 +1,93 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * A node in the parse tree representing a map literal, like {@code {"a": 1, "b": $x}}.
+ * Each evaluation gives a new `OrderedMap` holding the entries in the order they are
+ * written, so that a template changing the map does not change the literal.
+ */
+type MapLiteralNode struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Keys         []ExpressionNode
+	Values       []ExpressionNode
+	Type         string
+}
+
+func (node *MapLiteralNode) GetResourceName() string {
+	return node.ResourceName
+}
+
+func (node *MapLiteralNode) GetLineNumber() uint {
+	return node.LineNumber
+}
+
+func (node *MapLiteralNode) GetColumnNumber() uint {
+	return node.ColumnNumber
+}
+
+func (node *MapLiteralNode) String() string {
+	return ""
+}
+
+func (node *MapLiteralNode) IsWhitespace() bool {
+	return false
+}
+
+func (node *MapLiteralNode) IsHorizontalWhitespace() bool {
+	return false
+}
+
+func (node *MapLiteralNode) MarkExpressionNode() {
+
+}
+
+func (node *MapLiteralNode) Render(context *EvaluationContext, output *strings.Builder) {
+	renderExpression(context, output, node.Evaluate(context), false)
+}
+
+func (node *MapLiteralNode) IsTrue(context *EvaluationContext) bool {
+	return isExpressionTrue(node, context)
+}
+
+func (node *MapLiteralNode) Evaluate(context *EvaluationContext) interface{} {
+	orderedMap := NewOrderedMap()
+	for index, keyNode := range node.Keys {
+		key := keyNode.Evaluate(context)
+
+		_, err := orderedMap.Put(key, node.Values[index].Evaluate(context))
+		if err != nil {
+			message := fmt.Sprintf("A %T cannot be a key of a map literal", key)
+			panic(NewEvaluationError(keyNode.GetResourceName(), keyNode.GetLineNumber(), keyNode.GetColumnNumber(), message, err))
+		}
+	}
+
+	return orderedMap
+}
+
+func NewMapLiteralNode(name string, line uint, column uint, keys []ExpressionNode, values []ExpressionNode) *MapLiteralNode {
+	return &MapLiteralNode{
+		ResourceName: name,
+		LineNumber:   line,
+		ColumnNumber: column,
+		Keys:         keys,
+		Values:       values,
+		Type:         "MapLiteral",
+	}
+}
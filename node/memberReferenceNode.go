@@ -11,7 +11,10 @@
 
 package node
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 /**
  * A node in the parse tree that is a reference to a property of another reference, like
@@ -20,6 +23,7 @@ import "strings"
 type MemberReferenceNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Lhs          ReferenceNode
 	Id           string
 	Silent       bool
@@ -34,6 +38,10 @@ func (cons *MemberReferenceNode) GetLineNumber() uint {
 	return cons.LineNumber
 }
 
+func (cons *MemberReferenceNode) GetColumnNumber() uint {
+	return cons.ColumnNumber
+}
+
 func (node *MemberReferenceNode) String() string {
 	return ""
 }
@@ -54,14 +62,47 @@ func (node *MemberReferenceNode) MarkExpressionNode() {
 
 }
 
+func (node *MemberReferenceNode) IsTrue(context *EvaluationContext) bool {
+	return isExpressionTrue(node, context)
+}
+
 func (node *MemberReferenceNode) Render(context *EvaluationContext, output *strings.Builder) {
+	renderExpression(context, output, node.Evaluate(context), node.Silent)
+}
+
+/**
+ * Evaluates the property named by this node of the value of `Lhs`, as described for
+ * `getProperty`. For a silent reference like {@code $!order.customer.name}, a nil value
+ * anywhere along the way gives nil, which then renders as nothing.
+ */
+func (node *MemberReferenceNode) Evaluate(context *EvaluationContext) interface{} {
+	lhsValue := node.Lhs.Evaluate(context)
+	if isNil(lhsValue) {
+		if node.Silent {
+			return nil
+		}
+
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "Cannot get member "+node.Id+" of null value", nil))
+	}
+
+	value, found, err := getProperty(lhsValue, node.Id)
+	if err != nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "Cannot get member "+node.Id, err))
+	}
+
+	if found {
+		return value
+	}
 
+	message := fmt.Sprintf("Member %s does not correspond to a property of %T", node.Id, lhsValue)
+	panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
 }
 
 func NewMemberReferenceNode(lhs ReferenceNode, id string, silent bool) *MemberReferenceNode {
 	return &MemberReferenceNode{
 		ResourceName: lhs.GetResourceName(),
 		LineNumber:   lhs.GetLineNumber(),
+		ColumnNumber: lhs.GetColumnNumber(),
 		Id:           id,
 		Silent:       silent,
 		Lhs:          lhs,
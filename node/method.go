@@ -0,0 +1,307 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/**
+ * Calls the method with the given name of a value, as seen by {@code $value.name(...)}.
+ * The boolean result is false if the value has no such method. Go methods are exported,
+ * so a method written {@code $list.size()} in the template is looked for as {@code size}
+ * and then as {@code Size}. If there is no such method, JavaBean accessors like
+ * {@code getName()} are mapped as described for `invokeBeanAccessor`.
+ * A `PropertyHolder` has no methods, but a call without arguments
+ * like {@code $foreach.hasNext()} gives its property of the same name. Failing all that,
+ * Go implementations of the methods of Java's {@code String}, {@code List} and {@code Map}
+ * let templates written for Velocity call {@code $str.length()} or {@code $map.keySet()}
+ * on Go strings, slices and maps.
+ *
+ * <p>The arguments are converted to the types of the parameters as described for
+ * `convertArgument`. A variadic method takes any number of trailing arguments. If the last value
+ * returned by the method is an {@code error}, a non-nil error is returned as the error of
+ * the call, and the result is the first value returned, or nil if there is none.
+ */
+func invokeMethod(value interface{}, name string, arguments []interface{}) (interface{}, bool, error) {
+	method, found := findMethod(reflect.ValueOf(value), name)
+	if !found {
+		result, found, err := invokeBeanAccessor(value, name, arguments)
+		if found {
+			return result, true, err
+		}
+
+		holder, ok := value.(PropertyHolder)
+		if ok && len(arguments) == 0 {
+			property, found := holder.GetProperty(name)
+			return property, found, nil
+		}
+
+		shim, receiver, found := findJavaMethod(value, name)
+		if found {
+			result, err := invokeJavaMethod(shim, receiver, name, arguments)
+			return result, true, err
+		}
+
+		return nil, false, nil
+	}
+
+	methodType := method.Type()
+	parameterCount := methodType.NumIn()
+	if methodType.IsVariadic() {
+		if len(arguments) < parameterCount-1 {
+			return nil, true, fmt.Errorf("%s expects at least %d arguments, got %d", name, parameterCount-1, len(arguments))
+		}
+	} else if len(arguments) != parameterCount {
+		return nil, true, fmt.Errorf("%s expects %d arguments, got %d", name, parameterCount, len(arguments))
+	}
+
+	values := make([]reflect.Value, len(arguments))
+	for index, argument := range arguments {
+		var parameterType reflect.Type
+		if methodType.IsVariadic() && index >= parameterCount-1 {
+			parameterType = methodType.In(parameterCount - 1).Elem()
+		} else {
+			parameterType = methodType.In(index)
+		}
+
+		converted, ok := convertArgument(argument, parameterType)
+		if !ok {
+			return nil, true, fmt.Errorf("argument %d of %s cannot be converted from %T to %s", index+1, name, argument, parameterType)
+		}
+
+		values[index] = converted
+	}
+
+	results := method.Call(values)
+	if len(results) > 0 && methodType.Out(len(results)-1) == errorType {
+		last := results[len(results)-1]
+		if !last.IsNil() {
+			return nil, true, last.Interface().(error)
+		}
+
+		results = results[:len(results)-1]
+	}
+
+	if len(results) == 0 {
+		return nil, true, nil
+	}
+
+	return results[0].Interface(), true, nil
+}
+
+/**
+ * Calls a JavaBean accessor of a Go value that has no method of that name, so that
+ * templates written for Java types work unchanged. A getter {@code getName()} or
+ * {@code isName()} gives the property {@code name} as described for `getBeanProperty`,
+ * and a setter {@code setName($value)} sets the field {@code Name} as described for
+ * `setBeanProperty`. The boolean result is false if the name is not that of an accessor
+ * of the value.
+ */
+func invokeBeanAccessor(value interface{}, name string, arguments []interface{}) (interface{}, bool, error) {
+	for _, prefix := range []string{"get", "is", "set"} {
+		property := strings.TrimPrefix(name, prefix)
+		if property == name || property == "" || !unicode.IsUpper([]rune(property)[0]) {
+			continue
+		}
+
+		if prefix == "set" {
+			if len(arguments) != 1 {
+				return nil, false, nil
+			}
+
+			found, err := setBeanProperty(value, property, arguments[0])
+			return nil, found, err
+		}
+
+		if len(arguments) != 0 {
+			return nil, false, nil
+		}
+
+		return getBeanProperty(value, property)
+	}
+
+	return nil, false, nil
+}
+
+/**
+ * Finds the method with the given name, or the same name capitalized, of a value. A struct
+ * held by value is copied so that the methods with pointer receivers can be found too.
+ */
+func findMethod(reflected reflect.Value, name string) (reflect.Value, bool) {
+	if !reflected.IsValid() {
+		return reflect.Value{}, false
+	}
+
+	if reflected.Kind() == reflect.Struct && !reflected.CanAddr() {
+		copied := reflect.New(reflected.Type())
+		copied.Elem().Set(reflected)
+		reflected = copied
+	}
+
+	if reflected.NumMethod() == 0 {
+		return reflect.Value{}, false
+	}
+
+	for _, methodName := range []string{name, capitalize(name)} {
+		method := reflected.MethodByName(methodName)
+		if method.IsValid() {
+			return method, true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+/**
+ * Converts a value given as a method argument to the type of the parameter that receives
+ * it. The boolean result is false if that is not possible. Besides values assignable to
+ * the parameter type:
+ *
+ * <ul>
+ *   <li>nil is given as the zero value of a pointer, interface, map, slice, function or
+ *       channel parameter;
+ *   <li>a number is converted to any other numeric type that can hold its value, so that
+ *       an {@code int} can be given to an {@code int64} or a {@code float64}, but a
+ *       floating-point number is never given to an integer parameter;
+ *   <li>a string of a single character is given to a {@code rune} parameter;
+ *   <li>a value is converted to a named type with the same underlying kind, like a string
+ *       given to a parameter of {@code type Color string}.
+ * </ul>
+ */
+func convertArgument(value interface{}, target reflect.Type) (reflect.Value, bool) {
+	if value == nil {
+		switch target.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(target), true
+		}
+
+		return reflect.Value{}, false
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Type().AssignableTo(target) {
+		return reflected, true
+	}
+
+	if isNumericKind(reflected.Kind()) && isNumericKind(target.Kind()) {
+		return convertNumber(reflected, target)
+	}
+
+	if reflected.Kind() == reflect.String && target.Kind() == reflect.Int32 {
+		str := reflected.String()
+		char, size := utf8.DecodeRuneInString(str)
+		if size == 0 || size != len(str) {
+			return reflect.Value{}, false
+		}
+
+		return reflect.ValueOf(char).Convert(target), true
+	}
+
+	if reflected.Kind() == target.Kind() && reflected.Type().ConvertibleTo(target) {
+		return reflected.Convert(target), true
+	}
+
+	return reflect.Value{}, false
+}
+
+/**
+ * Converts a number to another numeric type, provided the value is within its range.
+ */
+func convertNumber(reflected reflect.Value, target reflect.Type) (reflect.Value, bool) {
+	converted := reflect.New(target).Elem()
+
+	switch {
+	case isIntKind(reflected.Kind()):
+		number := reflected.Int()
+		switch {
+		case isIntKind(target.Kind()):
+			if converted.OverflowInt(number) {
+				return reflect.Value{}, false
+			}
+
+			converted.SetInt(number)
+
+		case isUintKind(target.Kind()):
+			if number < 0 || converted.OverflowUint(uint64(number)) {
+				return reflect.Value{}, false
+			}
+
+			converted.SetUint(uint64(number))
+
+		default:
+			converted.SetFloat(float64(number))
+		}
+
+	case isUintKind(reflected.Kind()):
+		number := reflected.Uint()
+		switch {
+		case isIntKind(target.Kind()):
+			if number > 1<<63-1 || converted.OverflowInt(int64(number)) {
+				return reflect.Value{}, false
+			}
+
+			converted.SetInt(int64(number))
+
+		case isUintKind(target.Kind()):
+			if converted.OverflowUint(number) {
+				return reflect.Value{}, false
+			}
+
+			converted.SetUint(number)
+
+		default:
+			converted.SetFloat(float64(number))
+		}
+
+	default:
+		// floating-point numbers only widen, never becoming integers
+		if isIntKind(target.Kind()) || isUintKind(target.Kind()) {
+			return reflect.Value{}, false
+		}
+
+		number := reflected.Float()
+		if converted.OverflowFloat(number) {
+			return reflect.Value{}, false
+		}
+
+		converted.SetFloat(number)
+	}
+
+	return converted, true
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+
+	return false
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+
+	return false
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	return isIntKind(kind) || isUintKind(kind) || kind == reflect.Float32 || kind == reflect.Float64
+}
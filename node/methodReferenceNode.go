@@ -11,7 +11,11 @@
 
 package node
 
-import "strings"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
 
 /**
  * A node in the parse tree representing a method reference, like {@code $list.size()}.
@@ -19,6 +23,7 @@ import "strings"
 type MethodReferenceNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Lhs          ReferenceNode
 	Silent       bool
 	Id           string
@@ -34,6 +39,10 @@ func (cons *MethodReferenceNode) GetLineNumber() uint {
 	return cons.LineNumber
 }
 
+func (cons *MethodReferenceNode) GetColumnNumber() uint {
+	return cons.ColumnNumber
+}
+
 func (node *MethodReferenceNode) String() string {
 	return ""
 }
@@ -54,14 +63,75 @@ func (node *MethodReferenceNode) MarkExpressionNode() {
 
 }
 
+func (node *MethodReferenceNode) IsTrue(context *EvaluationContext) bool {
+	return isExpressionTrue(node, context)
+}
+
 func (node *MethodReferenceNode) Render(context *EvaluationContext, output *strings.Builder) {
+	renderExpression(context, output, node.Evaluate(context), node.Silent)
+}
+
+/**
+ * Evaluates the arguments and calls the method named by this node on the value of `Lhs`,
+ * as described for `invokeMethod`. An error returned by the method is raised as an
+ * `EvaluationError` at the position of this node.
+ *
+ * <p>A slice that is not held by a pointer, like a list evaluated from a list literal,
+ * cannot grow in place. If `Lhs` can be assigned to, the method is called on a copy of
+ * the slice instead, and if the method changed its length, as {@code $list.add($x)} does,
+ * the copy is assigned back to `Lhs` as {@code #set} would. Other references to the same
+ * slice do not see the new elements, as with Go's {@code append}.
+ */
+func (node *MethodReferenceNode) Evaluate(context *EvaluationContext) interface{} {
+	lhsValue := node.Lhs.Evaluate(context)
+	if isNil(lhsValue) {
+		if node.Silent {
+			return nil
+		}
+
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "Cannot invoke method "+node.Id+" on null value", nil))
+	}
+
+	arguments := make([]interface{}, len(node.Args))
+	for index, arg := range node.Args {
+		arguments[index] = arg.Evaluate(context)
+	}
+
+	receiver := lhsValue
+	reflected := reflect.ValueOf(lhsValue)
+	growable := reflected.Kind() == reflect.Slice && isAssignable(node.Lhs)
+	if growable {
+		// without spare capacity, so that growing the copy never writes into the slice
+		copied := reflect.New(reflected.Type())
+		copied.Elem().Set(reflected.Slice3(0, reflected.Len(), reflected.Len()))
+		receiver = copied.Interface()
+	}
+
+	value, found, err := invokeMethod(receiver, node.Id, arguments)
+	if err != nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "Method "+node.Id+" failed", err))
+	}
+
+	if !found {
+		message := fmt.Sprintf("Method %s does not exist on %T", node.Id, lhsValue)
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
+	}
+
+	if growable {
+		grown := reflect.ValueOf(receiver).Elem()
+		if grown.Len() != reflected.Len() {
+			assignReference(node.Lhs, grown.Interface(), context)
+		}
+	}
 
+	return value
 }
 
 func NewMethodReferenceNode(lhs ReferenceNode, id string, args []ExpressionNode, silent bool) *MethodReferenceNode {
 	return &MethodReferenceNode{
 		ResourceName: lhs.GetResourceName(),
 		LineNumber:   lhs.GetLineNumber(),
+		ColumnNumber: lhs.GetColumnNumber(),
 		Lhs:          lhs,
 		Silent:       silent,
 		Id:           id,
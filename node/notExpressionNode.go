@@ -16,6 +16,7 @@ import "strings"
 type NotExpressionNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Expression   ExpressionNode
 	Type         string
 }
@@ -28,6 +29,10 @@ func (cons *NotExpressionNode) GetLineNumber() uint {
 	return cons.LineNumber
 }
 
+func (cons *NotExpressionNode) GetColumnNumber() uint {
+	return cons.ColumnNumber
+}
+
 func (node *NotExpressionNode) String() string {
 	return ""
 }
@@ -45,7 +50,7 @@ func (node *NotExpressionNode) MarkExpressionNode() {
 }
 
 func (node *NotExpressionNode) Evaluate(context *EvaluationContext) interface{} {
-	return !node.Expression.IsTrue(context)
+	return !isExpressionDefinedAndTrue(node.Expression, context)
 }
 
 func (node *NotExpressionNode) IsTrue(context *EvaluationContext) bool {
@@ -60,6 +65,7 @@ func NewNotExpressionNode(expr ExpressionNode) *NotExpressionNode {
 	return &NotExpressionNode{
 		ResourceName: expr.GetResourceName(),
 		LineNumber:   expr.GetLineNumber(),
+		ColumnNumber: expr.GetColumnNumber(),
 		Expression:   expr,
 		Type:         "NotExpression",
 	}
@@ -78,6 +78,27 @@ var (
 
 var ALL_OPERATORS = []Operator{OR, AND, EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER, GREATER_OR_EQUAL, PLUS, MINUS, TIMES, DIVIDE, REMAINDER}
 
+/**
+ * The alphabetic forms of the binary operators, as in {@code #if ($a eq 1 and $b lt 2)}.
+ * Each is the same operator as its symbolic form. The alphabetic form of {@code !} is
+ * {@code not}, which is parsed as a unary operator.
+ */
+var WORD_OPERATORS = map[string]Operator{
+	"or":  OR,
+	"and": AND,
+	"eq":  EQUAL,
+	"ne":  NOT_EQUAL,
+	"lt":  LESS,
+	"le":  LESS_OR_EQUAL,
+	"gt":  GREATER,
+	"ge":  GREATER_OR_EQUAL,
+}
+
+/**
+ * The alphabetic form of the unary operator {@code !}.
+ */
+const WORD_NOT = "not"
+
 func GetPossibleOperators(char rune) []Operator {
 	ops := make([]Operator, 0, 5)
 
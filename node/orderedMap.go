@@ -0,0 +1,228 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"sangupta.com/velocity/utils"
+)
+
+/**
+ * A map that remembers the order in which its keys were first put, like Java's
+ * {@code LinkedHashMap}. It is the value of a map literal such as {@code {"a": 1, "b": $x}},
+ * which {@code #foreach} iterates over in that order. Its entries are reached as
+ * {@code $map.a} or {@code $map["a"]}, and it has the methods of {@code java.util.Map}
+ * that templates use.
+ *
+ * <p>Integer keys are stored as {@code int64} values, so that {@code $map[1]} finds the
+ * entry whatever the Go type of the integer. Keys must be comparable with {@code ==}, and
+ * the methods given a key that is not, like a slice, return an error.
+ */
+type OrderedMap struct {
+	keys   []interface{}
+	values map[interface{}]interface{}
+}
+
+func (orderedMap *OrderedMap) GetProperty(name string) (interface{}, bool) {
+	return orderedMap.values[name], true
+}
+
+/**
+ * Returns the value for the given key, or nil if there is none.
+ */
+func (orderedMap *OrderedMap) Get(key interface{}) (interface{}, error) {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return orderedMap.values[key], nil
+}
+
+/**
+ * Sets the value for the given key, which keeps its position if it is already present,
+ * and returns the previous value, or nil if there was none.
+ */
+func (orderedMap *OrderedMap) Put(key interface{}, value interface{}) (interface{}, error) {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, isPresent := orderedMap.values[key]
+	if !isPresent {
+		orderedMap.keys = append(orderedMap.keys, key)
+	}
+
+	orderedMap.values[key] = value
+	return previous, nil
+}
+
+/**
+ * Removes the entry for the given key and returns its value, or nil if there was none.
+ */
+func (orderedMap *OrderedMap) Remove(key interface{}) (interface{}, error) {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, isPresent := orderedMap.values[key]
+	if !isPresent {
+		return nil, nil
+	}
+
+	delete(orderedMap.values, key)
+	for index, existing := range orderedMap.keys {
+		if existing == key {
+			orderedMap.keys = append(orderedMap.keys[:index], orderedMap.keys[index+1:]...)
+			break
+		}
+	}
+
+	return previous, nil
+}
+
+func (orderedMap *OrderedMap) ContainsKey(key interface{}) (bool, error) {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, isPresent := orderedMap.values[key]
+	return isPresent, nil
+}
+
+func (orderedMap *OrderedMap) Size() int {
+	return len(orderedMap.keys)
+}
+
+func (orderedMap *OrderedMap) IsEmpty() bool {
+	return len(orderedMap.keys) == 0
+}
+
+/**
+ * Returns the keys in the order they were first put.
+ */
+func (orderedMap *OrderedMap) KeySet() []interface{} {
+	keys := make([]interface{}, len(orderedMap.keys))
+	copy(keys, orderedMap.keys)
+
+	return keys
+}
+
+/**
+ * Returns the values in the order of their keys.
+ */
+func (orderedMap *OrderedMap) Values() []interface{} {
+	values := make([]interface{}, len(orderedMap.keys))
+	for index, key := range orderedMap.keys {
+		values[index] = orderedMap.values[key]
+	}
+
+	return values
+}
+
+/**
+ * Returns the entries in the order of their keys.
+ */
+func (orderedMap *OrderedMap) EntrySet() []*MapEntry {
+	entries := make([]*MapEntry, len(orderedMap.keys))
+	for index, key := range orderedMap.keys {
+		entries[index] = &MapEntry{
+			Key:   key,
+			Value: orderedMap.values[key],
+		}
+	}
+
+	return entries
+}
+
+/**
+ * Renders the map as Java does, like {@code {a=1, b=2}}.
+ */
+func (orderedMap *OrderedMap) String() string {
+	builder := strings.Builder{}
+	builder.WriteRune('{')
+	for index, key := range orderedMap.keys {
+		if index > 0 {
+			builder.WriteString(", ")
+		}
+
+		builder.WriteString(utils.AsString(key))
+		builder.WriteRune('=')
+		builder.WriteString(utils.AsString(orderedMap.values[key]))
+	}
+	builder.WriteRune('}')
+
+	return builder.String()
+}
+
+/**
+ * Returns the key under which a value is stored, which for an integer is its
+ * {@code int64} value. It is an error for the key not to be comparable.
+ */
+func normalizeKey(key interface{}) (interface{}, error) {
+	if key != nil && !isComparable(reflect.ValueOf(key)) {
+		return nil, fmt.Errorf("a %T cannot be a key of a map", key)
+	}
+
+	number, ok := utils.AsNumber(key)
+	if ok && number.IsInteger() {
+		value, err := number.Int64()
+		if err == nil {
+			return value, nil
+		}
+	}
+
+	return key, nil
+}
+
+/**
+ * True if a value can be compared with {@code ==}, and so be a key of a Go map. Unlike
+ * the comparability of its type, this looks at what interfaces in the value hold.
+ */
+func isComparable(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return false
+
+	case reflect.Interface:
+		return value.IsNil() || isComparable(value.Elem())
+
+	case reflect.Array:
+		for index := 0; index < value.Len(); index++ {
+			if !isComparable(value.Index(index)) {
+				return false
+			}
+		}
+
+	case reflect.Struct:
+		for index := 0; index < value.NumField(); index++ {
+			if !isComparable(value.Field(index)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{
+		keys:   make([]interface{}, 0),
+		values: make(map[interface{}]interface{}),
+	}
+}
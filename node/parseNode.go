@@ -0,0 +1,94 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"sangupta.com/velocity/utils"
+)
+
+/**
+ * How deeply {@code #parse} and {@code #evaluate} directives may be nested, which
+ * protects against a resource that parses itself.
+ */
+const MAX_PARSE_DEPTH = 10
+
+/**
+ * A node in the parse tree representing a {@code #parse} directive that is evaluated when
+ * it is encountered during template evaluation, as Velocity does. The argument can then be
+ * any expression, such as a variable, and the directive can be placed inside {@code #if}.
+ */
+type ParseNode struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Argument     ExpressionNode
+	Type         string
+}
+
+func (node *ParseNode) String() string {
+	return ""
+}
+
+func (node *ParseNode) IsWhitespace() bool {
+	return false
+}
+
+func (node *ParseNode) IsHorizontalWhitespace() bool {
+	return false
+}
+
+func (node *ParseNode) MarkDirectiveNode() {
+
+}
+
+func (node *ParseNode) Render(context *EvaluationContext, output *strings.Builder) {
+	value := node.Argument.Evaluate(context)
+	if value == nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "#parse argument is null", nil))
+	}
+
+	name := utils.AsString(value)
+
+	if context.Runtime == nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "cannot #parse "+name+" without a runtime", nil))
+	}
+
+	if context.parseDepth >= MAX_PARSE_DEPTH {
+		message := fmt.Sprintf("#parse of %s exceeds the maximum depth of %d", name, MAX_PARSE_DEPTH)
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
+	}
+
+	parsed, err := context.Runtime.ParseResource(name)
+	if err != nil {
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "cannot #parse "+name, err))
+	}
+
+	context.parseDepth++
+	defer func() {
+		context.parseDepth--
+	}()
+
+	parsed.Render(context, output)
+}
+
+func NewParseNode(resourceName string, lineNumber uint, columnNumber uint, argument ExpressionNode) *ParseNode {
+	return &ParseNode{
+		ResourceName: resourceName,
+		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
+		Argument:     argument,
+		Type:         "Parse",
+	}
+}
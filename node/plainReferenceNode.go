@@ -11,10 +11,7 @@
 
 package node
 
-import (
-	"errors"
-	"strings"
-)
+import "strings"
 
 /**
  * A node in the parse tree that is a plain reference such as {@code $x}. This node may appear
@@ -23,6 +20,7 @@ import (
 type PlainReferenceNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	Id           string
 	Silent       bool
 	Type         string
@@ -36,6 +34,10 @@ func (cons *PlainReferenceNode) GetLineNumber() uint {
 	return cons.LineNumber
 }
 
+func (cons *PlainReferenceNode) GetColumnNumber() uint {
+	return cons.ColumnNumber
+}
+
 func (node *PlainReferenceNode) String() string {
 	return ""
 }
@@ -66,16 +68,28 @@ func (node *PlainReferenceNode) Render(context *EvaluationContext, output *strin
 
 func (node *PlainReferenceNode) Evaluate(context *EvaluationContext) interface{} {
 	if !context.IsVarDefined(node.Id) {
-		panic(errors.New("undefined reference: " + node.Id))
+		if node.Silent {
+			return nil
+		}
+
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, "Reference $"+node.Id+" is undefined", nil))
+	}
+
+	value := context.GetVar(node.Id)
+
+	argument, isArgument := value.(*MacroArgument)
+	if isArgument {
+		return argument.Evaluate()
 	}
 
-	return context.GetVar(node.Id)
+	return value
 }
 
-func NewPlainReferenceNode(name string, line uint, id string, silent bool) *PlainReferenceNode {
+func NewPlainReferenceNode(name string, line uint, column uint, id string, silent bool) *PlainReferenceNode {
 	return &PlainReferenceNode{
 		ResourceName: name,
 		LineNumber:   line,
+		ColumnNumber: column,
 		Id:           id,
 		Silent:       silent,
 		Type:         "PlainReference",
@@ -0,0 +1,261 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+/**
+ * Returns the property with the given name of a value, as seen by {@code $value.name}.
+ * The boolean result is false if the value has no such property. The property of a
+ * `PropertyHolder` is the one it gives. Otherwise pointers and interfaces are followed,
+ * a nil one giving a nil property, and the property is looked for, in order, as:
+ *
+ * <ul>
+ *   <li>the value of key {@code name} in a map with string keys;
+ *   <li>a JavaBean property, as described for `getBeanProperty`;
+ *   <li>for a map, a nil value standing for the missing key.
+ * </ul>
+ */
+func getProperty(value interface{}, name string) (interface{}, bool, error) {
+	holder, ok := value.(PropertyHolder)
+	if ok {
+		property, found := holder.GetProperty(name)
+		return property, found, nil
+	}
+
+	target, isNil := indirect(reflect.ValueOf(value))
+	if isNil {
+		return nil, true, nil
+	}
+
+	isMap := target.Kind() == reflect.Map && target.Type().Key().Kind() == reflect.String
+	if isMap {
+		entry := target.MapIndex(reflect.ValueOf(name).Convert(target.Type().Key()))
+		if entry.IsValid() {
+			return entry.Interface(), true, nil
+		}
+	}
+
+	property, found, err := getBeanProperty(value, name)
+	if found || !isMap {
+		return property, found, err
+	}
+
+	return nil, true, nil
+}
+
+/**
+ * Sets the property with the given name of a value, as {@code #set ($value.name = ...)}
+ * does. The boolean result is false if the value has no such property. The property of a
+ * map, including an `OrderedMap`, is set with {@code put}, and any other property with a
+ * setter as described for `invokeMethod`, such as {@code SetName} or the field {@code Name}
+ * of a struct.
+ */
+func setProperty(value interface{}, name string, property interface{}) (bool, error) {
+	method := "set" + capitalize(name)
+	arguments := []interface{}{property}
+
+	_, isOrderedMap := value.(*OrderedMap)
+	target, _ := indirect(reflect.ValueOf(value))
+	if isOrderedMap || target.Kind() == reflect.Map {
+		method = "put"
+		arguments = []interface{}{name, property}
+	}
+
+	_, found, err := invokeMethod(value, method, arguments)
+	return found, err
+}
+
+/**
+ * Returns the property with the given name of a Go value seen as a JavaBean, which
+ * is how {@code $user.name} and {@code $user.getName()} reach idiomatic Go types.
+ * Pointers and interfaces are followed, and the property is looked for, in order, as:
+ *
+ * <ol>
+ *   <li>an exported field called {@code Name}, including those of embedded structs;
+ *   <li>a method without parameters called {@code Name};
+ *   <li>a method without parameters called {@code GetName};
+ *   <li>a method without parameters called {@code IsName} that returns a {@code bool}.
+ * </ol>
+ *
+ * <p>A method gives the property if it returns a single value, or a value and an error.
+ * The methods with pointer receivers are found even for a struct held by value.
+ */
+func getBeanProperty(value interface{}, name string) (interface{}, bool, error) {
+	reflected := reflect.ValueOf(value)
+	target, isNil := indirect(reflected)
+	if isNil {
+		return nil, true, nil
+	}
+
+	if target.Kind() == reflect.Struct {
+		field, found := target.Type().FieldByName(capitalize(name))
+		if found && field.IsExported() {
+			fieldValue, err := target.FieldByIndexErr(field.Index)
+			if err != nil {
+				// a field promoted through a nil embedded pointer
+				return nil, true, nil
+			}
+
+			return fieldValue.Interface(), true, nil
+		}
+
+		// a struct held by value only has the methods with value receivers,
+		// so look on a pointer to a copy of it
+		if !target.CanAddr() {
+			copied := reflect.New(target.Type())
+			copied.Elem().Set(target)
+			reflected = copied
+		}
+	}
+
+	method, found := findPropertyMethod(reflected, name)
+	if found {
+		return callPropertyMethod(method)
+	}
+
+	return nil, false, nil
+}
+
+/**
+ * Sets the exported field called {@code Name} of a struct, as {@code $user.setName($value)}
+ * does for a JavaBean, converting the value as described for `convertArgument`. The boolean
+ * result is false if there is no such field. The struct must be reached through a pointer
+ * for its fields to be set.
+ */
+func setBeanProperty(value interface{}, name string, property interface{}) (bool, error) {
+	target, isNil := indirect(reflect.ValueOf(value))
+	if isNil || target.Kind() != reflect.Struct {
+		return false, nil
+	}
+
+	field, found := target.Type().FieldByName(capitalize(name))
+	if !found || !field.IsExported() {
+		return false, nil
+	}
+
+	fieldValue, err := target.FieldByIndexErr(field.Index)
+	if err != nil {
+		return true, fmt.Errorf("field %s is promoted through a nil pointer", field.Name)
+	}
+
+	if !fieldValue.CanSet() {
+		return true, fmt.Errorf("field %s of %s cannot be set as the struct is not held by a pointer", field.Name, target.Type())
+	}
+
+	converted, ok := convertArgument(property, field.Type)
+	if !ok {
+		return true, fmt.Errorf("field %s of type %s cannot be set to %T", field.Name, field.Type, property)
+	}
+
+	fieldValue.Set(converted)
+	return true, nil
+}
+
+/**
+ * Follows pointers and interfaces to the value they lead to. The boolean result is true
+ * if one of them is nil.
+ */
+func indirect(reflected reflect.Value) (reflect.Value, bool) {
+	for reflected.Kind() == reflect.Ptr || reflected.Kind() == reflect.Interface {
+		if reflected.IsNil() {
+			return reflected, true
+		}
+
+		reflected = reflected.Elem()
+	}
+
+	return reflected, !reflected.IsValid()
+}
+
+/**
+ * Finds the method of a value that gives the property with the given name, which
+ * must have no parameters and return a single value, or a value and an error. As
+ * for a JavaBean, a method {@code IsName} only gives the property if that value is
+ * a {@code bool}.
+ */
+func findPropertyMethod(reflected reflect.Value, name string) (reflect.Value, bool) {
+	if reflected.NumMethod() == 0 {
+		return reflect.Value{}, false
+	}
+
+	capitalized := capitalize(name)
+	for _, methodName := range []string{capitalized, "Get" + capitalized, "Is" + capitalized} {
+		method := reflected.MethodByName(methodName)
+		if !method.IsValid() {
+			continue
+		}
+
+		methodType := method.Type()
+		if methodType.NumIn() != 0 {
+			continue
+		}
+
+		if methodType.NumOut() != 1 && (methodType.NumOut() != 2 || methodType.Out(1) != errorType) {
+			continue
+		}
+
+		if methodName == "Is"+capitalized && methodType.Out(0).Kind() != reflect.Bool {
+			continue
+		}
+
+		return method, true
+	}
+
+	return reflect.Value{}, false
+}
+
+func callPropertyMethod(method reflect.Value) (interface{}, bool, error) {
+	results := method.Call(nil)
+	if len(results) == 2 && !results[1].IsNil() {
+		return nil, true, results[1].Interface().(error)
+	}
+
+	return results[0].Interface(), true, nil
+}
+
+/**
+ * Returns the name with its first letter in upper case, which is how the exported
+ * Go field or method for property {@code name} would be called.
+ */
+func capitalize(name string) string {
+	first, size := utf8.DecodeRuneInString(name)
+	if first == utf8.RuneError {
+		return name
+	}
+
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+/**
+ * True if the value is nil or a nil pointer.
+ */
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Ptr:
+		return reflected.IsNil()
+	}
+
+	return false
+}
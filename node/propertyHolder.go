@@ -0,0 +1,21 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+/**
+ * Implemented by values that give templates access to their properties themselves,
+ * such as the {@code $foreach} scope, so that {@code $value.name} is resolved by calling
+ * `GetProperty("name")`. The boolean result is false if there is no such property.
+ */
+type PropertyHolder interface {
+	GetProperty(name string) (interface{}, bool)
+}
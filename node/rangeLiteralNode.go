@@ -11,11 +11,22 @@
 
 package node
 
-import "strings"
+import (
+	"fmt"
+	"math"
+	"strings"
 
+	"sangupta.com/velocity/utils"
+)
+
+/**
+ * A node in the parse tree representing a range literal, like {@code [1..$n]} or
+ * {@code [$n..1]}.
+ */
 type RangeLiteralNode struct {
 	ResourceName string
 	LineNumber   uint
+	ColumnNumber uint
 	First        ExpressionNode
 	Last         ExpressionNode
 	Type         string
@@ -29,6 +40,10 @@ func (cons *RangeLiteralNode) GetLineNumber() uint {
 	return cons.LineNumber
 }
 
+func (cons *RangeLiteralNode) GetColumnNumber() uint {
+	return cons.ColumnNumber
+}
+
 func (node *RangeLiteralNode) String() string {
 	return ""
 }
@@ -53,35 +68,43 @@ func (node *RangeLiteralNode) IsTrue(context *EvaluationContext) bool {
 	return isExpressionTrue(node, context)
 }
 
+/**
+ * Evaluates the range into an `IntRange`. Both bounds must be integers, and the number
+ * of integers in the range must fit in an {@code int}.
+ */
 func (node *RangeLiteralNode) Evaluate(context *EvaluationContext) interface{} {
-	// TODO: fix this
-
-	// int from = first.intValue(context);
-	//   int to = last.intValue(context);
-	//   ImmutableSortedSet<Integer> set =
-	//       (from <= to)
-	//           ? ContiguousSet.closed(from, to)
-	//           : ContiguousSet.closed(to, from).descendingSet();
-	//   return new ForwardingSortedSet<Integer>() {
-	//     @Override
-	//     protected ImmutableSortedSet<Integer> delegate() {
-	//       return set;
-	//     }
-
-	//     @Override
-	//     public String toString() {
-	//       // ContiguousSet returns [1..3] whereas Velocity uses [1, 2, 3].
-	//       return set.asList().toString();
-	//     }
-	//   };
-
-	return nil
+	first := node.bound(node.First, context)
+	last := node.bound(node.Last, context)
+
+	intRange := NewIntRange(first, last)
+	if intRange.span() >= math.MaxInt {
+		message := fmt.Sprintf("Range [%d..%d] has more than %d elements", first, last, math.MaxInt)
+		panic(NewEvaluationError(node.ResourceName, node.LineNumber, node.ColumnNumber, message, nil))
+	}
+
+	return intRange
+}
+
+func (node *RangeLiteralNode) bound(bound ExpressionNode, context *EvaluationContext) int64 {
+	value := bound.Evaluate(context)
+
+	number, ok := utils.AsNumber(value)
+	if ok && number.IsInteger() {
+		integer, err := number.Int64()
+		if err == nil {
+			return integer
+		}
+	}
+
+	message := fmt.Sprintf("Range bound must be an integer, not %T %v", value, utils.AsString(value))
+	panic(NewEvaluationError(bound.GetResourceName(), bound.GetLineNumber(), bound.GetColumnNumber(), message, nil))
 }
 
-func NewRangeLiteralNode(resourceName string, lineNumber uint, first ExpressionNode, last ExpressionNode) *RangeLiteralNode {
+func NewRangeLiteralNode(resourceName string, lineNumber uint, columnNumber uint, first ExpressionNode, last ExpressionNode) *RangeLiteralNode {
 	return &RangeLiteralNode{
 		ResourceName: resourceName,
 		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
 		First:        first,
 		Last:         last,
 		Type:         "RangeLiteral",
@@ -0,0 +1,37 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import "io"
+
+/**
+ * Services that nodes need from the engine while a template is being
+ * evaluated, such as parsing other resources. The parser package provides
+ * the implementation, through `EvaluationContext.Runtime`.
+ */
+type Runtime interface {
+	/**
+	 * Returns the parsed tree of the named resource.
+	 */
+	ParseResource(name string) (Node, error)
+
+	/**
+	 * Opens the named resource for reading its raw contents.
+	 */
+	OpenResource(name string) (io.ReadCloser, error)
+
+	/**
+	 * Returns the parsed tree of a template given as a string, as for
+	 * {@code #evaluate}.
+	 */
+	ParseString(text string) (Node, error)
+}
@@ -11,20 +11,30 @@
 
 package node
 
-import "strings"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
 
 /**
  * A node in the parse tree representing a {@code #set} construct. Evaluating
  * {@code #set ($x = 23)} will set {@code $x} to the value 23. It does not in itself produce
  * any text in the output.
  *
- * <p>Velocity supports setting values within arrays or collections, with for example
- * {@code $set ($x[$i] = $y)}. That is not currently supported here.
+ * <p>As in Velocity, the target can also be an element of a list or map, like
+ * {@code #set ($x[$i] = $y)} or {@code #set ($map["k"] = $y)}, or a property, like
+ * {@code #set ($map.key = $y)} or {@code #set ($user.name = $y)}. An element of a list is set
+ * as {@code $x.set($i, $y)} would, so the index must be within bounds, and an element of a
+ * map or a property of a map as {@code $map.put("key", $y)} would. Any other property is set
+ * as {@code $user.setName($y)} would, which sets the field {@code Name} of a struct reached
+ * through a pointer.
  */
 type SetNode struct {
 	ResourceName string
 	LineNumber   uint
-	Variable     string
+	ColumnNumber uint
+	Target       ReferenceNode
 	Expression   ExpressionNode
 	Type         string
 }
@@ -46,14 +56,89 @@ func (node *SetNode) MarkDirectiveNode() {
 }
 
 func (node *SetNode) Render(context *EvaluationContext, output *strings.Builder) {
+	assignReference(node.Target, node.Expression.Evaluate(context), context)
+}
+
+/**
+ * Assigns a value to a reference as {@code #set} does, raising an `EvaluationError` at the
+ * position of the reference if that is not possible.
+ */
+func assignReference(target ReferenceNode, value interface{}, context *EvaluationContext) {
+	switch target := target.(type) {
+	case *PlainReferenceNode:
+		context.SetVar(target.Id, value)
+
+	case *IndexReferenceNode:
+		setIndex(target, value, context)
+
+	case *MemberReferenceNode:
+		setMember(target, value, context)
+
+	default:
+		message := fmt.Sprintf("Cannot assign to a %T", target)
+		panic(NewEvaluationError(target.GetResourceName(), target.GetLineNumber(), target.GetColumnNumber(), message, nil))
+	}
+}
+
+/**
+ * True if a reference can be assigned to as described for `assignReference`.
+ */
+func isAssignable(target ReferenceNode) bool {
+	switch target.(type) {
+	case *PlainReferenceNode, *IndexReferenceNode, *MemberReferenceNode:
+		return true
+	}
+
+	return false
+}
 
+/**
+ * Sets an element of a list with {@code set}, or of anything else with {@code put}.
+ */
+func setIndex(target *IndexReferenceNode, value interface{}, context *EvaluationContext) {
+	lhsValue := target.Lhs.Evaluate(context)
+	if isNil(lhsValue) {
+		panic(NewEvaluationError(target.ResourceName, target.LineNumber, target.ColumnNumber, "Cannot set an element of null value", nil))
+	}
+
+	index := target.Index.Evaluate(context)
+
+	method := "put"
+	reflected, _ := indirect(reflect.ValueOf(lhsValue))
+	if reflected.Kind() == reflect.Slice || reflected.Kind() == reflect.Array {
+		method = "set"
+	}
+
+	_, found, err := invokeMethod(lhsValue, method, []interface{}{index, value})
+	if err != nil || !found {
+		message := fmt.Sprintf("Cannot set an element of a %T", lhsValue)
+		panic(NewEvaluationError(target.ResourceName, target.LineNumber, target.ColumnNumber, message, err))
+	}
+}
+
+func setMember(target *MemberReferenceNode, value interface{}, context *EvaluationContext) {
+	lhsValue := target.Lhs.Evaluate(context)
+	if isNil(lhsValue) {
+		panic(NewEvaluationError(target.ResourceName, target.LineNumber, target.ColumnNumber, "Cannot set member "+target.Id+" of null value", nil))
+	}
+
+	found, err := setProperty(lhsValue, target.Id, value)
+	if err != nil {
+		panic(NewEvaluationError(target.ResourceName, target.LineNumber, target.ColumnNumber, "Cannot set member "+target.Id, err))
+	}
+
+	if !found {
+		message := fmt.Sprintf("Member %s does not correspond to a settable property of %T", target.Id, lhsValue)
+		panic(NewEvaluationError(target.ResourceName, target.LineNumber, target.ColumnNumber, message, nil))
+	}
 }
 
-func NewSetNode(id string, expression ExpressionNode) *SetNode {
+func NewSetNode(target ReferenceNode, expression ExpressionNode) *SetNode {
 	return &SetNode{
 		ResourceName: expression.GetResourceName(),
 		LineNumber:   expression.GetLineNumber(),
-		Variable:     id,
+		ColumnNumber: expression.GetColumnNumber(),
+		Target:       target,
 		Expression:   expression,
 		Type:         "Set",
 	}
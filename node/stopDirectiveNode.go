@@ -0,0 +1,61 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import "strings"
+
+/**
+ * A node in the parse tree representing a {@code #stop} directive, which ends the rendering
+ * of the template. What was rendered before the {@code #stop} is kept.
+ */
+type StopDirectiveNode struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Type         string
+}
+
+func (node *StopDirectiveNode) String() string {
+	return ""
+}
+
+func (node *StopDirectiveNode) IsWhitespace() bool {
+	return false
+}
+
+func (node *StopDirectiveNode) IsHorizontalWhitespace() bool {
+	return false
+}
+
+func (node *StopDirectiveNode) MarkDirectiveNode() {
+
+}
+
+func (node *StopDirectiveNode) Render(context *EvaluationContext, output *strings.Builder) {
+	panic(&StopSignal{})
+}
+
+func NewStopDirectiveNode(resourceName string, lineNumber uint, columnNumber uint) *StopDirectiveNode {
+	return &StopDirectiveNode{
+		ResourceName: resourceName,
+		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
+		Type:         "Stop",
+	}
+}
+
+/**
+ * Raised by a {@code #stop} to end the rendering of the template. Whoever started the
+ * rendering recovers it and keeps the output rendered so far.
+ */
+type StopSignal struct {
+}
@@ -0,0 +1,81 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package node
+
+import "strings"
+
+/**
+ * A node in the parse tree representing a double-quoted string literal that contains
+ * references or directives, such as {@code "Hello, $name"}. The contents of the literal
+ * are parsed as a template, which is rendered each time the literal is evaluated.
+ * Literals with nothing to interpolate are parsed as a `ConstantExpressionNode` instead.
+ */
+type StringLiteralNode struct {
+	ResourceName string
+	LineNumber   uint
+	ColumnNumber uint
+	Body         Node
+	Type         string
+}
+
+func (node *StringLiteralNode) GetResourceName() string {
+	return node.ResourceName
+}
+
+func (node *StringLiteralNode) GetLineNumber() uint {
+	return node.LineNumber
+}
+
+func (node *StringLiteralNode) GetColumnNumber() uint {
+	return node.ColumnNumber
+}
+
+func (node *StringLiteralNode) String() string {
+	return ""
+}
+
+func (node *StringLiteralNode) IsWhitespace() bool {
+	return false
+}
+
+func (node *StringLiteralNode) IsHorizontalWhitespace() bool {
+	return false
+}
+
+func (node *StringLiteralNode) MarkExpressionNode() {
+
+}
+
+func (node *StringLiteralNode) Evaluate(context *EvaluationContext) interface{} {
+	builder := strings.Builder{}
+	node.Body.Render(context, &builder)
+
+	return builder.String()
+}
+
+func (node *StringLiteralNode) IsTrue(context *EvaluationContext) bool {
+	return isExpressionTrue(node, context)
+}
+
+func (node *StringLiteralNode) Render(context *EvaluationContext, output *strings.Builder) {
+	renderExpression(context, output, node.Evaluate(context), false)
+}
+
+func NewStringLiteralNode(resourceName string, lineNumber uint, columnNumber uint, body Node) *StringLiteralNode {
+	return &StringLiteralNode{
+		ResourceName: resourceName,
+		LineNumber:   lineNumber,
+		ColumnNumber: columnNumber,
+		Body:         body,
+		Type:         "StringLiteral",
+	}
+}
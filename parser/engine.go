@@ -0,0 +1,169 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package parser
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"sangupta.com/velocity/node"
+	"sangupta.com/velocity/resource"
+)
+
+/**
+ * Decides when the resource named in a {@code #parse} directive is parsed.
+ */
+type ParseMode int
+
+const (
+	/**
+	 * The resource is parsed along with the template that contains the directive and
+	 * integrated into it as if it had been written inline, as EscapeVelocity does. The
+	 * argument must be a string literal.
+	 */
+	PARSE_INLINE ParseMode = iota
+
+	/**
+	 * The resource is parsed when the directive is evaluated, as Velocity does. The
+	 * argument can be any expression and the directive can be used inside {@code #if}.
+	 */
+	PARSE_AT_RUNTIME
+)
+
+/**
+ * The resource name given to templates parsed for {@code #evaluate}.
+ */
+const EVALUATE_RESOURCE_NAME = "#evaluate"
+
+/**
+ * The `node.Runtime` shared by a template and every resource parsed on its behalf.
+ * It keeps the loader, parse mode and directives the template was parsed with, so
+ * that changing or reusing the parser afterwards does not affect the template. It
+ * loads resources through that loader, parses them with the same settings, and keeps
+ * the results so that each resource is parsed only once, as are the strings given to
+ * {@code #evaluate} that were used recently. The lock only guards the caches: two
+ * renders that need the same resource at the same time may both parse it.
+ */
+type engine struct {
+	loader     resource.ResourceLoader
+	parseMode  ParseMode
+	directives map[string]node.Directive
+	macros     map[string]*Macro
+	lock       sync.Mutex
+	resources  map[string]node.Node
+	snippets   *snippetCache
+}
+
+func (engine *engine) ParseResource(name string) (node.Node, error) {
+	engine.lock.Lock()
+	parsed, ok := engine.resources[name]
+	engine.lock.Unlock()
+
+	if ok {
+		return parsed, nil
+	}
+
+	if engine.loader == nil {
+		return nil, fmt.Errorf("%w: %s", resource.ErrResourceNotFound, name)
+	}
+
+	contents, err := resource.ReadResource(engine.loader, name)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := engine.derive(name, []rune(contents)).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	engine.lock.Lock()
+	engine.resources[name] = template.Root
+	engine.lock.Unlock()
+
+	return template.Root, nil
+}
+
+func (engine *engine) ParseString(text string) (node.Node, error) {
+	engine.lock.Lock()
+	parsed, ok := engine.snippets.get(text)
+	engine.lock.Unlock()
+
+	if ok {
+		return parsed, nil
+	}
+
+	template, err := engine.derive(EVALUATE_RESOURCE_NAME, []rune(text)).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	engine.lock.Lock()
+	engine.snippets.put(text, template.Root)
+	engine.lock.Unlock()
+
+	return template.Root, nil
+}
+
+func (engine *engine) OpenResource(name string) (io.ReadCloser, error) {
+	if engine.loader == nil {
+		return nil, fmt.Errorf("%w: %s", resource.ErrResourceNotFound, name)
+	}
+
+	return engine.loader.OpenResource(name)
+}
+
+/**
+ * Creates a parser for a resource of the template, with the settings the
+ * template was parsed with.
+ */
+func (engine *engine) derive(resourceName string, chars []rune) *Parser {
+	return &Parser{
+		Chars:        chars,
+		ResourceName: resourceName,
+		Loader:       engine.loader,
+		ParseMode:    engine.parseMode,
+		Directives:   engine.directives,
+		engine:       engine,
+		derived:      true,
+	}
+}
+
+/**
+ * Returns a copy of the macros of the top-level template, which resources
+ * parsed at runtime can call.
+ */
+func (engine *engine) inheritedMacros() map[string]*Macro {
+	macros := make(map[string]*Macro, len(engine.macros))
+	for name, macro := range engine.macros {
+		macros[name] = macro
+	}
+
+	return macros
+}
+
+func newEngine(parser *Parser) *engine {
+	directives := make(map[string]node.Directive, len(parser.Directives))
+	for name, directive := range parser.Directives {
+		directives[name] = directive
+	}
+
+	return &engine{
+		loader:     parser.Loader,
+		parseMode:  parser.ParseMode,
+		directives: directives,
+		macros:     make(map[string]*Macro),
+		resources:  make(map[string]node.Node),
+		snippets:   newSnippetCache(),
+	}
+}
@@ -11,5 +11,42 @@
 
 package parser
 
+import "sangupta.com/velocity/node"
+
+/**
+ * A macro defined in a template with {@code #macro (name $param1 $param2) body #end}.
+ * The definition does not appear in the parse tree itself: calls of the macro are
+ * represented by `node.MacroCallNode` instances which are bound to their `Macro` once
+ * the whole template has been parsed.
+ */
 type Macro struct {
+	ResourceName   string
+	LineNumber     uint
+	ColumnNumber   uint
+	Name           string
+	ParameterNames []string
+	Body           node.Node
+}
+
+func (macro *Macro) GetName() string {
+	return macro.Name
+}
+
+func (macro *Macro) GetParameterNames() []string {
+	return macro.ParameterNames
+}
+
+func (macro *Macro) GetBody() node.Node {
+	return macro.Body
+}
+
+func NewMacro(resourceName string, lineNumber uint, columnNumber uint, name string, parameterNames []string, body node.Node) *Macro {
+	return &Macro{
+		ResourceName:   resourceName,
+		LineNumber:     lineNumber,
+		ColumnNumber:   columnNumber,
+		Name:           name,
+		ParameterNames: parameterNames,
+		Body:           body,
+	}
 }
@@ -12,12 +12,18 @@
 package parser
 
 import (
-	"errors"
+	"strings"
 
 	"sangupta.com/velocity/node"
+	"sangupta.com/velocity/utils"
 )
 
 type OperatorParser struct {
+	/**
+	 * The parser whose input we are reading operators and operands from.
+	 */
+	parser *Parser
+
 	/**
 	 * The operator we have just scanned, in the same way that {@link #c} is the character we have
 	 * just read. If we were not able to scan an operator, this will be {@link Operator#STOP}.
@@ -31,16 +37,16 @@ type OperatorParser struct {
  *
  * @return the parsed subexpression
  */
-func (op *OperatorParser) Parse(parser Parser, lhs node.ExpressionNode, minPrecedence uint) node.ExpressionNode {
+func (op *OperatorParser) Parse(lhs node.ExpressionNode, minPrecedence uint) node.ExpressionNode {
 	for op.currentOperator.GetPrecendence() >= minPrecedence {
 		operator := op.currentOperator
 
-		rhs := parser.parseUnaryExpression()
+		rhs := op.parser.parseUnaryExpression()
 
-		op.nextOperator(parser)
+		op.nextOperator()
 
 		for op.currentOperator.GetPrecendence() > operator.GetPrecendence() {
-			rhs = op.Parse(parser, rhs, op.currentOperator.GetPrecendence())
+			rhs = op.Parse(rhs, op.currentOperator.GetPrecendence())
 		}
 
 		lhs = node.NewBinaryExpressionNode(lhs, operator, rhs)
@@ -53,9 +59,15 @@ func (op *OperatorParser) Parse(parser Parser, lhs node.ExpressionNode, minPrece
  * Updates {@link #currentOperator} to be an operator read from the input,
  * or {@link Operator#STOP} if there is none.
  */
-func (op *OperatorParser) nextOperator(parser Parser) {
+func (op *OperatorParser) nextOperator() {
+	parser := op.parser
 	parser.skipSpace()
 
+	if utils.IsAsciiLetter(parser.c) {
+		op.currentOperator = op.nextWordOperator()
+		return
+	}
+
 	possibleOperators := node.GetPossibleOperators(parser.c)
 	if len(possibleOperators) == 0 {
 		op.currentOperator = node.STOP
@@ -63,29 +75,63 @@ func (op *OperatorParser) nextOperator(parser Parser) {
 	}
 
 	firstChar := parser.c
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
 	parser.next()
 
 	var operator *node.Operator
-	for _, op := range possibleOperators {
-		if op.GetSymbolLen() == 1 {
+	for index := range possibleOperators {
+		candidate := possibleOperators[index]
+		if candidate.GetSymbolLen() == 1 {
 			if operator != nil {
-				panic(errors.New("operator must be nil"))
+				panic(parser.parseErrorAt(startLine, startColumn, string(firstChar), "Ambiguous operator starting with "+string(firstChar)))
 			}
 
-			operator = &op
-		} else if op.GetSecondRune() == parser.c {
+			operator = &candidate
+		} else if candidate.GetSecondRune() == parser.c {
 			parser.next()
-			operator = &op
+			operator = &candidate
 		}
 	}
 
 	if operator == nil {
-		panic(errors.New("Expected possibleOperators, not just " + string(firstChar)))
+		symbols := make([]string, 0, len(possibleOperators))
+		for _, candidate := range possibleOperators {
+			symbols = append(symbols, candidate.GetSymbol())
+		}
+
+		message := "Expected " + strings.Join(symbols, " or ") + ", not just " + string(firstChar)
+		panic(parser.parseErrorAt(startLine, startColumn, string(firstChar), message))
 	}
 
 	op.currentOperator = *operator
 }
 
-func NewOperatorParser() *OperatorParser {
-	return &OperatorParser{}
+/**
+ * Reads one of the alphabetic operators like {@code eq} or {@code and}, returning
+ * {@link Operator#STOP} without reading anything if the input does not start with one.
+ * The operator must be a whole word, so that the {@code eq} of {@code equals} is not one.
+ */
+func (op *OperatorParser) nextWordOperator() node.Operator {
+	for word, operator := range node.WORD_OPERATORS {
+		if op.parser.lookingAtWord(word) {
+			op.parser.skipWord(word)
+			return operator
+		}
+	}
+
+	return node.STOP
+}
+
+/**
+ * Creates an operator parser reading from the given parser, positioned on the
+ * first operator after the left-hand side that has just been parsed.
+ */
+func NewOperatorParser(parser *Parser) *OperatorParser {
+	op := &OperatorParser{
+		parser: parser,
+	}
+	op.nextOperator()
+
+	return op
 }
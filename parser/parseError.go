@@ -0,0 +1,73 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * The error returned by `Parser.Parse` when a template cannot be parsed.
+ * It records where in the resource the problem was detected, the token
+ * found there and an excerpt of the offending line with a caret under
+ * the column, suitable for showing to a template author.
+ */
+type ParseError struct {
+	ResourceName string
+	Line         uint
+	Column       uint
+	Token        string
+	Message      string
+	Excerpt      string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", err.ResourceName, err.Line, err.Column, err.Message)
+}
+
+/**
+ * Builds the excerpt for the given 1-based line and column of the source:
+ * the text of the line followed by a second line with a caret under the
+ * column. Tabs before the column are kept so that the caret lines up.
+ */
+func renderExcerpt(chars []rune, line uint, column uint) string {
+	var current uint = 1
+	start := 0
+	for start < len(chars) && current < line {
+		if chars[start] == '\n' {
+			current++
+		}
+		start++
+	}
+
+	end := start
+	for end < len(chars) && chars[end] != '\n' && chars[end] != '\r' {
+		end++
+	}
+
+	text := chars[start:end]
+
+	var builder strings.Builder
+	builder.WriteString(string(text))
+	builder.WriteRune('\n')
+	for index := 0; index+1 < int(column); index++ {
+		if index < len(text) && text[index] == '\t' {
+			builder.WriteRune('\t')
+		} else {
+			builder.WriteRune(' ')
+		}
+	}
+	builder.WriteRune('^')
+
+	return builder.String()
+}
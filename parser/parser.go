@@ -14,10 +14,13 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"runtime"
+	"strconv"
 	"strings"
 	"unicode"
 
 	node "sangupta.com/velocity/node"
+	resource "sangupta.com/velocity/resource"
 	utils "sangupta.com/velocity/utils"
 )
 
@@ -29,48 +32,288 @@ type Parser struct {
 	Chars        []rune
 	c            rune
 	ResourceName string
+	Loader       resource.ResourceLoader
+	ParseMode    ParseMode
+	Directives   map[string]node.Directive
 	pointer      uint `default:"0"`
-	pushback     int
-	macros       map[string]Macro
+	pushback     rune
+	hasPushback  bool
+	macros       map[string]*Macro
+	macroCalls   []*node.MacroCallNode
 	line         uint
+	column       uint
+	engine       *engine
+	derived      bool
+	depth        int
+	breakable    int
+	literalQuote rune
 }
 
-func (parser *Parser) Parse() (Template, error) {
-	parser.c = parser.Chars[0]
+/**
+ * Parses the template held in `Chars`. Any problem found in the template
+ * is returned as a `*ParseError` describing where it was detected, rather
+ * than escaping as a panic.
+ */
+func (parser *Parser) Parse() (template Template, err error) {
+	defer func() {
+		recovered := recover()
+		if recovered != nil {
+			err = parser.recoverParseError(recovered)
+		}
+	}()
+
+	// each template gets its own engine, so that reusing a parser does not
+	// carry macros or parsed resources over from the templates it parsed before
+	isTopLevel := !parser.derived
+	if isTopLevel {
+		parser.engine = newEngine(parser)
+	}
+
+	parser.macros = parser.engine.inheritedMacros()
+	parser.macroCalls = make([]*node.MacroCallNode, 0)
+	parser.start()
 
 	parseResult := parser.parseToStop(isEofNode, "outside any construct")
-	root := node.NewConsNode(parser.ResourceName, parser.lineNumber(), parseResult.Nodes)
+	root := node.NewConsNode(parser.ResourceName, 1, 1, parseResult.Nodes)
+
+	parser.bindMacroCalls()
+
+	if isTopLevel {
+		parser.engine.macros = parser.macros
+	}
 
 	return Template{
 		Root:   root,
 		Macros: parser.macros,
 		Type:   "Template",
+		engine: parser.engine,
 	}, nil
 }
 
+/**
+ * Registers a custom directive, which templates parsed by this parser, and any
+ * resources they parse, can then use. The standard directives cannot be replaced.
+ */
+func (parser *Parser) RegisterDirective(directive node.Directive) error {
+	name := directive.GetName()
+	if standardDirectives[name] {
+		return errors.New("cannot replace the standard directive #" + name)
+	}
+
+	if parser.Directives == nil {
+		parser.Directives = make(map[string]node.Directive)
+	}
+
+	parser.Directives[name] = directive
+	return nil
+}
+
+/**
+ * Positions the parser on the first character of `Chars`.
+ */
+func (parser *Parser) start() {
+	parser.pointer = 0
+	parser.hasPushback = false
+	parser.line = 1
+	parser.column = 1
+	if len(parser.Chars) == 0 {
+		parser.c = EOF
+	} else {
+		parser.c = parser.Chars[0]
+	}
+}
+
+/**
+ * Binds every macro call seen during parsing to the definition of the macro it
+ * calls. This happens once the whole template has been parsed, so that a macro
+ * can be called before it is defined.
+ */
+func (parser *Parser) bindMacroCalls() {
+	for _, call := range parser.macroCalls {
+		macro, isDefined := parser.macros[call.Name]
+		if !isDefined {
+			message := "#" + call.Name + " is neither a standard directive nor a macro that has been defined"
+			panic(parser.parseErrorAt(call.LineNumber, call.ColumnNumber, "#"+call.Name, message))
+		}
+
+		expected := len(macro.ParameterNames)
+		actual := len(call.Thunks)
+		if expected != actual {
+			message := fmt.Sprintf("Wrong number of arguments to #%s: expected %d, got %d", call.Name, expected, actual)
+			panic(parser.parseErrorAt(call.LineNumber, call.ColumnNumber, "#"+call.Name, message))
+		}
+
+		call.SetMacro(macro)
+	}
+}
+
+/**
+ * Converts a value recovered from a panic during parsing into a `*ParseError`.
+ * Errors raised without position information, such as those from
+ * `utils.AssertRune`, are attributed to the current position of the parser.
+ * Runtime errors indicate a bug in the parser rather than in the template
+ * and are re-raised.
+ */
+func (parser *Parser) recoverParseError(recovered interface{}) *ParseError {
+	switch value := recovered.(type) {
+	case *ParseError:
+		return value
+
+	case runtime.Error:
+		panic(value)
+
+	case error:
+		return parser.parseError(value.Error())
+
+	default:
+		panic(recovered)
+	}
+}
+
+/**
+ * Creates a `*ParseError` with the given message at the current position of
+ * the parser, using the current character as the offending token.
+ */
+func (parser *Parser) parseError(message string) *ParseError {
+	return parser.parseErrorAt(parser.lineNumber(), parser.columnNumber(), parser.currentToken(), message)
+}
+
+/**
+ * Creates a `*ParseError` with the given message for a token found at the
+ * given line and column.
+ */
+func (parser *Parser) parseErrorAt(line uint, column uint, token string, message string) *ParseError {
+	return &ParseError{
+		ResourceName: parser.ResourceName,
+		Line:         line,
+		Column:       column,
+		Token:        token,
+		Message:      message,
+		Excerpt:      renderExcerpt(parser.Chars, line, column),
+	}
+}
+
+/**
+ * Describes the current character for use in error messages.
+ */
+func (parser *Parser) currentToken() string {
+	if parser.c == EOF {
+		return "EOF"
+	}
+
+	return string(parser.c)
+}
+
 /**
  * Gets the next character from the reader and assigns it to {@code c}. If there are no more
  * characters, sets {@code c} to {@link #EOF} if it is not already.
  */
 func (parser *Parser) next() {
 	if parser.c != EOF {
-		if parser.pushback >= 0 {
+		if parser.c == '\n' {
+			parser.line++
+			parser.column = 1
+		} else {
+			parser.column++
+		}
+
+		if !parser.hasPushback {
 			parser.pointer++
-			if parser.pointer >= uint(len(parser.Chars)) {
-				parser.c = EOF
-			} else {
-				parser.c = parser.Chars[parser.pointer]
-				if parser.c == '\n' {
-					parser.line++
-				}
-			}
+			parser.c = parser.readChar()
 		} else {
-			parser.c = rune(parser.pushback)
-			parser.pushback = -1
+			parser.c = parser.pushback
+			parser.hasPushback = false
 		}
 	}
 }
 
+/**
+ * Returns the character at the current position in `Chars`, or {@link #EOF} if there is
+ * none. While the contents of an interpolated string literal are being parsed, the quote
+ * that closes the literal also reads as {@link #EOF}, and a doubled quote reads as a
+ * single quote character.
+ */
+func (parser *Parser) readChar() rune {
+	if parser.pointer >= uint(len(parser.Chars)) {
+		return EOF
+	}
+
+	char := parser.Chars[parser.pointer]
+	if parser.literalQuote == 0 || char != parser.literalQuote {
+		return char
+	}
+
+	following := parser.pointer + 1
+	if following < uint(len(parser.Chars)) && parser.Chars[following] == char {
+		parser.pointer = following
+		parser.column++
+		return char
+	}
+
+	return EOF
+}
+
+/**
+ * Returns the character after {@code c} without reading it. The quote that closes an
+ * interpolated string literal is returned as {@link #EOF}.
+ */
+func (parser *Parser) peek() rune {
+	if parser.hasPushback {
+		return parser.pushback
+	}
+
+	return parser.charAt(parser.pointer + 1)
+}
+
+/**
+ * True if the input, starting with {@code c}, is the given word followed by a character
+ * that cannot be part of an identifier. Nothing is read.
+ */
+func (parser *Parser) lookingAtWord(word string) bool {
+	if parser.c == EOF {
+		return false
+	}
+
+	// after a pushback, the character following c is the one at the pointer
+	index := parser.pointer + 1
+	if parser.hasPushback {
+		index = parser.pointer
+	}
+
+	char := parser.c
+	for _, expected := range word {
+		if char != expected {
+			return false
+		}
+
+		char = parser.charAt(index)
+		index++
+	}
+
+	return !utils.IsIdChar(char)
+}
+
+/**
+ * Reads the given word, which `lookingAtWord` has found at {@code c}.
+ */
+func (parser *Parser) skipWord(word string) {
+	for range word {
+		parser.next()
+	}
+}
+
+/**
+ * Returns the character at the given index of `Chars` without reading it, or {@link #EOF}
+ * if there is none or it is the quote that closes an interpolated string literal.
+ */
+func (parser *Parser) charAt(index uint) rune {
+	if index >= uint(len(parser.Chars)) || (parser.literalQuote != 0 && parser.Chars[index] == parser.literalQuote) {
+		return EOF
+	}
+
+	return parser.Chars[index]
+}
+
 /**
  * If {@code c} is a space character, keeps reading until {@code c} is a non-space character or
  * there are no more characters.
@@ -90,8 +333,12 @@ func (parser *Parser) skipSpace() {
  * essentially puts us back in the state we were in before we read {@code y}.
  */
 func (parser *Parser) doPushback(char rune) {
-	parser.pushback = int(parser.c)
+	// the saved character can be EOF, as when a reference ends an interpolated
+	// string literal, so whether there is one is kept apart from the character
+	parser.pushback = parser.c
+	parser.hasPushback = true
 	parser.c = char
+	parser.column--
 }
 
 /**
@@ -114,7 +361,7 @@ func (parser *Parser) expect(expected rune) {
 		return
 	}
 
-	panic(utils.ParseException("Expected: " + string(expected) + ", found: " + string(parser.c)))
+	panic(parser.parseError("Expected " + string(expected) + ", found " + parser.currentToken()))
 }
 
 /**
@@ -133,7 +380,8 @@ func (parser *Parser) parseToStop(stopClasses func(node node.Node) bool, context
 	for true {
 		localNode = parser.parseNode()
 
-		if stopClasses(localNode) {
+		_, isStopNode := localNode.(node.StopNode)
+		if isStopNode {
 			break
 		}
 
@@ -145,11 +393,12 @@ func (parser *Parser) parseToStop(stopClasses func(node node.Node) bool, context
 		}
 	}
 
-	stopNode, ok := localNode.(node.StopNode)
-	if !ok {
-		panic(errors.New("Found " + localNode.String() + " " + contextDescription))
+	if !stopClasses(localNode) {
+		panic(parser.parseError("Found " + localNode.String() + " " + contextDescription))
 	}
 
+	stopNode := localNode.(node.StopNode)
+
 	return ParseResult{
 		Nodes: nodes,
 		stop:  stopNode,
@@ -201,7 +450,7 @@ func (parser *Parser) parseNode() node.Node {
 	}
 
 	if parser.c == EOF {
-		return node.NewEofNode(parser.ResourceName, parser.lineNumber())
+		return node.NewEofNode(parser.ResourceName, parser.lineNumber(), parser.columnNumber())
 	}
 
 	return parser.parseNonDirective()
@@ -213,16 +462,18 @@ func (parser *Parser) parseNode() node.Node {
  */
 func (parser *Parser) parseLineComment() node.Node {
 	lineNumber := parser.lineNumber()
+	columnNumber := parser.columnNumber()
 	for parser.c != '\n' && parser.c != EOF {
 		parser.next()
 	}
 	parser.next()
-	return node.NewCommentNode(parser.ResourceName, lineNumber)
+	return node.NewCommentNode(parser.ResourceName, lineNumber, columnNumber)
 }
 
 func (parser *Parser) parseBlockComment() node.Node {
 	utils.AssertRune(parser.c, '*')
 	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
 	var lastC rune
 	lastC = 0
 	parser.next()
@@ -234,13 +485,17 @@ func (parser *Parser) parseBlockComment() node.Node {
 	}
 	parser.next() // this may read EOF twice, which works
 
-	return node.NewCommentNode(parser.ResourceName, startLine)
+	return node.NewCommentNode(parser.ResourceName, startLine, startColumn)
 }
 
 func (parser *Parser) lineNumber() uint {
 	return parser.line
 }
 
+func (parser *Parser) columnNumber() uint {
+	return parser.column
+}
+
 func (parser *Parser) parseHashSquare() node.Node {
 	// We've just seen #[ which might be the start of a #[[quoted block]]#. If the next character
 	// is not another [ then it's not a quoted block, but it *is* a literal #[ followed by whatever
@@ -254,12 +509,13 @@ func (parser *Parser) parseHashSquare() node.Node {
 	}
 
 	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
 	parser.next()
 
 	var sb strings.Builder
 	for true {
 		if parser.c == EOF {
-			panic(utils.ParseException("Unterminated #[[ - did not see matching ]]#" + " in " + parser.ResourceName + " at " + fmt.Sprint(startLine)))
+			panic(parser.parseErrorAt(startLine, startColumn, "#[[", "Unterminated #[[ - did not see matching ]]#"))
 		}
 
 		if parser.c == '#' {
@@ -279,7 +535,7 @@ func (parser *Parser) parseHashSquare() node.Node {
 
 	quoted := sb.String()
 	quoted = quoted[0 : sb.Len()-2]
-	return node.NewConstantExpressionNode(parser.ResourceName, parser.lineNumber(), quoted)
+	return node.NewConstantExpressionNode(parser.ResourceName, startLine, startColumn, quoted)
 }
 
 /**
@@ -289,6 +545,9 @@ func (parser *Parser) parseHashSquare() node.Node {
  * cases we also parse the complete directive, for example a complete {@code #foreach...#end}.
  */
 func (parser *Parser) parseDirective() node.Node {
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
+
 	var directive string
 	if parser.c == '{' {
 		parser.next()
@@ -301,18 +560,18 @@ func (parser *Parser) parseDirective() node.Node {
 	var localNode node.Node
 	switch directive {
 	case "end":
-		localNode = node.NewEndNode(parser.ResourceName, parser.lineNumber())
+		localNode = node.NewEndNode(parser.ResourceName, startLine, startColumn)
 		break
 
 	case "if":
 		return parser.parseIfOrElseIf("#if")
 
 	case "elseif":
-		localNode = node.NewElseIfNode(parser.ResourceName, parser.lineNumber())
+		localNode = node.NewElseIfNode(parser.ResourceName, startLine, startColumn)
 		break
 
 	case "else":
-		localNode = node.NewElseNode(parser.ResourceName, parser.lineNumber())
+		localNode = node.NewElseNode(parser.ResourceName, startLine, startColumn)
 		break
 
 	case "foreach":
@@ -323,13 +582,42 @@ func (parser *Parser) parseDirective() node.Node {
 		break
 
 	case "parse":
-		localNode = parser.parseParse()
+		localNode = parser.parseParse(startLine, startColumn)
+		break
+
+	case "include":
+		localNode = parser.parseInclude(startLine, startColumn)
+		break
+
+	case "break":
+		localNode = parser.parseBreak(startLine, startColumn)
+		break
+
+	case "stop":
+		localNode = node.NewStopDirectiveNode(parser.ResourceName, startLine, startColumn)
 		break
 
 	case "macro":
 		return parser.parseMacroDefinition()
 
+	case "define":
+		return parser.parseDefine()
+
+	case "evaluate":
+		localNode = parser.parseEvaluate(startLine, startColumn)
+		break
+
 	default:
+		custom, isCustom := parser.Directives[directive]
+		if isCustom {
+			if custom.GetKind() == node.BLOCK_DIRECTIVE {
+				return parser.parseCustomDirective(custom, startLine, startColumn)
+			}
+
+			localNode = parser.parseCustomDirective(custom, startLine, startColumn)
+			break
+		}
+
 		localNode = parser.parsePossibleMacroCall(directive)
 	}
 
@@ -369,6 +657,9 @@ func (parser *Parser) parsePlainText(initialChars string) node.Node {
 }
 
 func (parser *Parser) parsePlainTextWithBuilder(builder *strings.Builder) node.Node {
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
+
 	for true {
 		if parser.c == EOF || parser.c == '$' || parser.c == '#' {
 			break
@@ -379,7 +670,7 @@ func (parser *Parser) parsePlainTextWithBuilder(builder *strings.Builder) node.N
 		parser.next()
 	}
 
-	return node.NewConstantExpressionNode(parser.ResourceName, parser.lineNumber(), builder.String())
+	return node.NewConstantExpressionNode(parser.ResourceName, startLine, startColumn, builder.String())
 }
 
 func (parser *Parser) parseNonDirective() node.Node {
@@ -414,6 +705,7 @@ func (parser *Parser) parseDollar() node.Node {
 
 func (parser *Parser) parseIfOrElseIf(directive string) node.Node {
 	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
 	parser.expect('(')
 
 	var condition node.ExpressionNode
@@ -426,21 +718,22 @@ func (parser *Parser) parseIfOrElseIf(directive string) node.Node {
 	parsedTruePart = parser.skipNewlineAndParseToStop(isElseOrElseIfEndNode, description)
 
 	var truePart node.Node
-	truePart = node.NewConsNode(parser.ResourceName, startLine, parsedTruePart.Nodes)
+	truePart = node.NewConsNode(parser.ResourceName, startLine, startColumn, parsedTruePart.Nodes)
 
 	var falsePart node.Node
 
 	if isEndNode(parsedTruePart.stop) {
-		falsePart = node.EmptyNode(parser.ResourceName, parser.lineNumber())
+		falsePart = node.EmptyNode(parser.ResourceName, parser.lineNumber(), parser.columnNumber())
 	} else if isElseIfNode(parsedTruePart.stop) {
 		falsePart = parser.parseIfOrElseIf("#elseif")
 	} else {
 		elseLine := parser.lineNumber()
+		elseColumn := parser.columnNumber()
 		parsedFalsePart := parser.parseToStop(isEndNode, "parsing #else starting on line "+fmt.Sprint(elseLine))
-		falsePart = node.NewConsNode(parser.ResourceName, elseLine, parsedFalsePart.Nodes)
+		falsePart = node.NewConsNode(parser.ResourceName, elseLine, elseColumn, parsedFalsePart.Nodes)
 	}
 
-	return node.NewIfNode(parser.ResourceName, startLine, condition, truePart, falsePart)
+	return node.NewIfNode(parser.ResourceName, startLine, startColumn, condition, truePart, falsePart)
 }
 
 func (parser *Parser) skipNewlineAndParseToStop(stopClasses func(node node.Node) bool, contextDescription string) ParseResult {
@@ -456,10 +749,15 @@ func (parser *Parser) skipNewlineAndParseToStop(stopClasses func(node node.Node)
  *
  * <pre>{@code
  * #foreach ( $<id> in <expression> ) <body> #end
+ * #foreach ( $<id> in <expression> ) <body> #else <else-body> #end
  * }</pre>
+ *
+ * <p>As in Velocity 2, the optional {@code #else} part is rendered instead of the body when the
+ * collection is null or empty.
  */
 func (parser *Parser) parseForEach() node.Node {
 	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
 
 	parser.expect('(')
 	parser.expect('$')
@@ -481,7 +779,7 @@ func (parser *Parser) parseForEach() node.Node {
 	}
 
 	if bad {
-		panic(utils.ParseException("Expected 'in' for #foreach"))
+		panic(parser.parseError("Expected 'in' for #foreach"))
 	}
 
 	parser.next()
@@ -492,26 +790,42 @@ func (parser *Parser) parseForEach() node.Node {
 	parser.expect(')')
 
 	var parsedBody ParseResult
-	parsedBody = parser.skipNewlineAndParseToStop(isEndNode, "parsing #foreach starting on line "+fmt.Sprint(startLine))
+	parser.breakable++
+	parsedBody = parser.skipNewlineAndParseToStop(isElseOrEndNode, "parsing #foreach starting on line "+fmt.Sprint(startLine))
+	parser.breakable--
 
 	var body node.Node
-	body = node.NewConsNode(parser.ResourceName, startLine, parsedBody.Nodes)
+	body = node.NewConsNode(parser.ResourceName, startLine, startColumn, parsedBody.Nodes)
+
+	var elseBody node.Node
+	if !isEndNode(parsedBody.stop) {
+		elseLine := parser.lineNumber()
+		elseColumn := parser.columnNumber()
+		parsedElseBody := parser.parseToStop(isEndNode, "parsing #else of #foreach starting on line "+fmt.Sprint(startLine))
+		elseBody = node.NewConsNode(parser.ResourceName, elseLine, elseColumn, parsedElseBody.Nodes)
+	}
 
-	return node.NewForEachNode(parser.ResourceName, startLine, id, collection, body)
+	return node.NewForEachNode(parser.ResourceName, startLine, startColumn, id, collection, body, elseBody)
 }
 
 /**
- * Parses a {@code #set} token from the reader.
+ * Parses a {@code #set} token from the reader. The target is a variable, or an element
+ * or property of one like {@code $list[$i]} or {@code $user.name}, but not a method call.
  *
  * <pre>{@code
- * #set ( $<id> = <expression> )
+ * #set ( $<reference-no-brace> = <expression> )
  * }</pre>
  */
 func (parser *Parser) parseSet() node.Node {
 	parser.expect('(')
 	parser.expect('$')
 
-	id := parser.parseId("#set variable")
+	target := parser.parseReferenceNoBrace(false)
+
+	_, isMethodCall := target.(*node.MethodReferenceNode)
+	if isMethodCall {
+		panic(parser.parseErrorAt(target.GetLineNumber(), target.GetColumnNumber(), "#set", "#set cannot assign to a method call"))
+	}
 
 	parser.expect('=')
 
@@ -520,7 +834,31 @@ func (parser *Parser) parseSet() node.Node {
 
 	parser.expect(')')
 
-	return node.NewSetNode(id, expression)
+	return node.NewSetNode(target, expression)
+}
+
+/**
+ * Parses a {@code #define} token from the reader.
+ *
+ * <pre>{@code
+ * #define ( $<id> ) <body> #end
+ * }</pre>
+ */
+func (parser *Parser) parseDefine() node.Node {
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
+
+	parser.expect('(')
+	parser.expect('$')
+
+	id := parser.parseId("#define variable")
+
+	parser.expect(')')
+
+	parsedBody := parser.skipNewlineAndParseToStop(isEndNode, "parsing #define starting on line "+fmt.Sprint(startLine))
+	body := node.NewConsNode(parser.ResourceName, startLine, startColumn, parsedBody.Nodes)
+
+	return node.NewDefineNode(parser.ResourceName, startLine, startColumn, id, body)
 }
 
 /**
@@ -530,16 +868,235 @@ func (parser *Parser) parseSet() node.Node {
  * #parse ( <string-literal> )
  * }</pre>
  *
- * <p>The way this works is inconsistent with Velocity. In Velocity, the {@code #parse} directive
- * is evaluated when it is encountered during template evaluation. That means that the argument
- * can be a variable, and it also means that you can use {@code #if} to choose whether or not
- * to do the {@code #parse}. Neither of those is true in EscapeVelocity. The contents of the
- * {@code #parse} are integrated into the containing template pretty much as if they had been
- * written inline. That also means that EscapeVelocity allows forward references to macros
- * inside {@code #parse} directives, which Velocity does not.
+ * <p>With `PARSE_INLINE`, the default, the way this works is inconsistent with Velocity. In
+ * Velocity, the {@code #parse} directive is evaluated when it is encountered during template
+ * evaluation. That means that the argument can be a variable, and it also means that you can use
+ * {@code #if} to choose whether or not to do the {@code #parse}. Neither of those is true in
+ * EscapeVelocity. The contents of the {@code #parse} are integrated into the containing template
+ * pretty much as if they had been written inline. That also means that EscapeVelocity allows
+ * forward references to macros inside {@code #parse} directives, which Velocity does not.
+ *
+ * <p>With `PARSE_AT_RUNTIME` the directive becomes a `node.ParseNode` and behaves as it does in
+ * Velocity.
+ */
+func (parser *Parser) parseParse(startLine uint, startColumn uint) node.Node {
+	parser.expect('(')
+	parser.skipSpace()
+
+	var argument node.ExpressionNode
+	if parser.ParseMode == PARSE_AT_RUNTIME {
+		argument = parser.parsePrimary()
+	} else if parser.c == '"' || parser.c == '\'' {
+		argument = parser.parseResourceName()
+	} else {
+		panic(parser.parseError("#parse only supported with string literal argument"))
+	}
+
+	parser.expect(')')
+
+	if parser.ParseMode == PARSE_AT_RUNTIME {
+		return node.NewParseNode(parser.ResourceName, startLine, startColumn, argument)
+	}
+
+	name := argument.(*node.ConstantExpressionNode).String()
+	if parser.depth >= node.MAX_PARSE_DEPTH {
+		message := fmt.Sprintf("#parse of %s exceeds the maximum depth of %d", name, node.MAX_PARSE_DEPTH)
+		panic(parser.parseErrorAt(startLine, startColumn, "#parse", message))
+	}
+
+	if parser.Loader == nil {
+		panic(parser.parseErrorAt(startLine, startColumn, "#parse", "Cannot #parse "+name+" without a ResourceLoader"))
+	}
+
+	contents, err := resource.ReadResource(parser.Loader, name)
+	if err != nil {
+		panic(parser.parseErrorAt(startLine, startColumn, "#parse", "Cannot #parse "+name+": "+err.Error()))
+	}
+
+	nested := parser.engine.derive(name, []rune(contents))
+	nested.depth = parser.depth + 1
+	nested.breakable = parser.breakable
+	nested.macros = parser.macros
+	nested.macroCalls = make([]*node.MacroCallNode, 0)
+	nested.start()
+
+	parseResult := nested.parseToStop(isEofNode, "outside any construct")
+	parser.macroCalls = append(parser.macroCalls, nested.macroCalls...)
+
+	return node.NewConsNode(parser.ResourceName, startLine, startColumn, parseResult.Nodes)
+}
+
+/**
+ * Parses a {@code #break} token from the reader.
+ *
+ * <pre>{@code
+ * #break
+ * #break ( <reference> )
+ * }</pre>
+ *
+ * <p>The reference, like {@code $foreach.parent} or {@code $macro}, says which scope to leave.
+ * It is an error for {@code #break} to appear outside any {@code #foreach} or {@code #macro}.
  */
-func (parser *Parser) parseParse() node.Node {
-	panic(errors.New("parsing inner templated is not yet implemented"))
+func (parser *Parser) parseBreak(startLine uint, startColumn uint) node.Node {
+	// a resource parsed for #parse or #evaluate does not know whether the directive
+	// is inside a #foreach or macro, so that is left to `node.BreakNode` to check
+	if parser.breakable == 0 && !parser.derived {
+		panic(parser.parseErrorAt(startLine, startColumn, "#break", "#break is only allowed inside #foreach or #macro"))
+	}
+
+	var scope node.ExpressionNode
+	if parser.c == '(' {
+		parser.next()
+		parser.expect('$')
+		scope = parser.parseRequiredReference()
+		parser.expect(')')
+	}
+
+	return node.NewBreakNode(parser.ResourceName, startLine, startColumn, scope)
+}
+
+/**
+ * Parses an {@code #include} token from the reader.
+ *
+ * <pre>{@code
+ * #include ( <resource> )
+ * #include ( <resource> <resource> ...)
+ * #include ( <resource> , <resource> ...)
+ * <resource> -> <string-literal> | <primary>
+ * }</pre>
+ *
+ * <p>The resources are only read when the directive is evaluated, so the arguments can be
+ * variables. As with macro calls, the arguments are optionally separated by commas.
+ */
+func (parser *Parser) parseInclude(startLine uint, startColumn uint) node.Node {
+	parser.expect('(')
+	parser.skipSpace()
+
+	arguments := make([]node.ExpressionNode, 0)
+	for parser.c != ')' {
+		arguments = append(arguments, parser.parsePrimary())
+
+		if parser.c == ',' {
+			parser.nextNonSpace()
+		}
+	}
+
+	parser.next()
+
+	if len(arguments) == 0 {
+		panic(parser.parseErrorAt(startLine, startColumn, "#include", "#include needs at least one resource name"))
+	}
+
+	return node.NewIncludeNode(parser.ResourceName, startLine, startColumn, arguments)
+}
+
+/**
+ * Parses an {@code #evaluate} token from the reader.
+ *
+ * <pre>{@code
+ * #evaluate ( <string-literal> )
+ * #evaluate ( <primary> )
+ * }</pre>
+ */
+func (parser *Parser) parseEvaluate(startLine uint, startColumn uint) node.Node {
+	parser.expect('(')
+	parser.skipSpace()
+
+	argument := parser.parsePrimary()
+
+	parser.expect(')')
+
+	return node.NewEvaluateNode(parser.ResourceName, startLine, startColumn, argument)
+}
+
+/**
+ * Parses the name of a resource given as a quoted string, as in {@code #parse ("header.vm")}.
+ * The name is taken literally: it is not interpolated even if double-quoted.
+ */
+func (parser *Parser) parseResourceName() node.ExpressionNode {
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
+
+	quote := parser.c
+	parser.next()
+
+	var name strings.Builder
+	for parser.c != quote {
+		if parser.c == EOF || parser.c == '\n' {
+			panic(parser.parseErrorAt(startLine, startColumn, string(quote), "Unterminated string literal"))
+		}
+
+		name.WriteRune(parser.c)
+		parser.next()
+	}
+
+	parser.nextNonSpace()
+
+	return node.NewConstantExpressionNode(parser.ResourceName, startLine, startColumn, name.String())
+}
+
+/**
+ * Parses the use of a directive registered with `RegisterDirective`.
+ *
+ * <pre>{@code
+ * #<id>
+ * #<id> ( <arg1> <arg2> ...)
+ * #<id> ( <arg1> , <arg2> ...)
+ * }</pre>
+ *
+ * <p>followed, for a `node.BLOCK_DIRECTIVE`, by a body and {@code #end}. Since the arguments
+ * are optional, spaces after the name are only skipped if they are followed by {@code (}.
+ */
+func (parser *Parser) parseCustomDirective(directive node.Directive, startLine uint, startColumn uint) node.Node {
+	name := directive.GetName()
+
+	arguments := make([]node.ExpressionNode, 0)
+	if parser.peekPastHorizontalSpace() == '(' {
+		parser.expect('(')
+		parser.skipSpace()
+
+		for parser.c != ')' {
+			arguments = append(arguments, parser.parsePrimary())
+
+			if parser.c == ',' {
+				parser.nextNonSpace()
+			}
+		}
+
+		parser.next()
+	}
+
+	err := directive.CheckArguments(arguments)
+	if err != nil {
+		panic(parser.parseErrorAt(startLine, startColumn, "#"+name, "Invalid arguments to #"+name+": "+err.Error()))
+	}
+
+	var body node.Node
+	if directive.GetKind() == node.BLOCK_DIRECTIVE {
+		parsedBody := parser.skipNewlineAndParseToStop(isEndNode, "parsing #"+name+" starting on line "+fmt.Sprint(startLine))
+		body = node.NewConsNode(parser.ResourceName, startLine, startColumn, parsedBody.Nodes)
+	}
+
+	return node.NewCustomDirectiveNode(parser.ResourceName, startLine, startColumn, directive, arguments, body)
+}
+
+/**
+ * Returns the first character that is not a space or tab, starting with the current one,
+ * without moving past it.
+ */
+func (parser *Parser) peekPastHorizontalSpace() rune {
+	if parser.hasPushback || (parser.c != ' ' && parser.c != '\t') {
+		return parser.c
+	}
+
+	for index := int(parser.pointer) + 1; index < len(parser.Chars); index++ {
+		char := parser.Chars[index]
+		if char != ' ' && char != '\t' {
+			return char
+		}
+	}
+
+	return EOF
 }
 
 /**
@@ -552,7 +1109,47 @@ func (parser *Parser) parseParse() node.Node {
  * <p>Macro parameters are optionally separated by commas.
  */
 func (parser *Parser) parseMacroDefinition() node.Node {
-	panic(errors.New("working with macro's is not yet implemented"))
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
+
+	parser.expect('(')
+	parser.skipSpace()
+
+	name := parser.parseId("Macro name")
+
+	parameterNames := make([]string, 0)
+	for true {
+		parser.skipSpace()
+		if parser.c == ',' {
+			parser.nextNonSpace()
+		}
+
+		if parser.c == ')' {
+			parser.next()
+			break
+		}
+
+		if parser.c != '$' {
+			panic(parser.parseError("Macro parameters should look like $name"))
+		}
+
+		parser.next()
+		parameterNames = append(parameterNames, parser.parseId("Macro parameter name"))
+	}
+
+	description := "parsing #macro(" + name + ") starting on line " + fmt.Sprint(startLine)
+	parser.breakable++
+	parsedBody := parser.skipNewlineAndParseToStop(isEndNode, description)
+	parser.breakable--
+
+	// Consistently with Velocity, the first definition of a macro is the one that counts.
+	_, isDefined := parser.macros[name]
+	if !isDefined {
+		body := node.NewConsNode(parser.ResourceName, startLine, startColumn, parsedBody.Nodes)
+		parser.macros[name] = NewMacro(parser.ResourceName, startLine, startColumn, name, parameterNames, body)
+	}
+
+	return node.EmptyNode(parser.ResourceName, startLine, startColumn)
 }
 
 /**
@@ -569,7 +1166,33 @@ func (parser *Parser) parseMacroDefinition() node.Node {
  * }</pre>
  */
 func (parser *Parser) parsePossibleMacroCall(directive string) node.Node {
-	panic(errors.New("working with macro's is not yet implemented"))
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
+
+	parser.skipSpace()
+	if parser.c != '(' {
+		panic(parser.parseError("#" + directive + " is neither a standard directive nor a macro call"))
+	}
+
+	parser.nextNonSpace()
+
+	thunks := make([]node.ExpressionNode, 0)
+	for parser.c != ')' {
+		thunks = append(thunks, parser.parsePrimary())
+
+		// The documentation doesn't say so, but you can apparently have an optional comma in
+		// macro calls.
+		if parser.c == ',' {
+			parser.nextNonSpace()
+		}
+	}
+
+	parser.next()
+
+	call := node.NewMacroCallNode(parser.ResourceName, startLine, startColumn, directive, thunks)
+	parser.macroCalls = append(parser.macroCalls, call)
+
+	return call
 }
 
 /**
@@ -638,10 +1261,12 @@ func (parser *Parser) parseRequiredReference() node.ReferenceNode {
  * }</pre>
  */
 func (parser *Parser) parseReferenceNoBrace(silent bool) node.ReferenceNode {
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
 	id := parser.parseId("Reference")
 
 	var lhs node.ReferenceNode
-	lhs = node.NewPlainReferenceNode(parser.ResourceName, parser.lineNumber(), id, silent)
+	lhs = node.NewPlainReferenceNode(parser.ResourceName, startLine, startColumn, id, silent)
 
 	return parser.parseReferenceSuffix(lhs, silent)
 }
@@ -683,7 +1308,26 @@ func (parser *Parser) parseReferenceSuffix(lhs node.ReferenceNode, silent bool)
  *     {@code $x} in {@code $x.foo} or {@code $x.foo()}.
  */
 func (parser *Parser) parseReferenceMember(lhs node.ReferenceNode, silent bool) node.ReferenceNode {
-	return nil
+	utils.AssertRune(parser.c, '.')
+	parser.next()
+
+	if !utils.IsAsciiLetter(parser.c) {
+		// We've seen something like $x. where the next character is not a letter. Treat the $x
+		// as a reference and the . as plain text.
+		parser.doPushback('.')
+		return lhs
+	}
+
+	id := parser.parseId("Member")
+
+	var reference node.ReferenceNode
+	if parser.c == '(' {
+		reference = parser.parseReferenceMethodParams(lhs, id, silent)
+	} else {
+		reference = node.NewMemberReferenceNode(lhs, id, silent)
+	}
+
+	return parser.parseReferenceSuffix(reference, silent)
 }
 
 /**
@@ -699,7 +1343,24 @@ func (parser *Parser) parseReferenceMember(lhs node.ReferenceNode, silent bool)
  *     {@code $x} in {@code $x.foo()}.
  */
 func (parser *Parser) parseReferenceMethodParams(lhs node.ReferenceNode, id string, silent bool) node.ReferenceNode {
-	return nil
+	utils.AssertRune(parser.c, '(')
+	parser.nextNonSpace()
+
+	args := make([]node.ExpressionNode, 0)
+	if parser.c != ')' {
+		args = append(args, parser.parsePrimaryWithOptionalNull(true))
+		for parser.c == ',' {
+			parser.nextNonSpace()
+			args = append(args, parser.parsePrimaryWithOptionalNull(true))
+		}
+
+		if parser.c != ')' {
+			panic(parser.parseError("Expected )"))
+		}
+	}
+
+	parser.next()
+	return node.NewMethodReferenceNode(lhs, id, args, silent)
 }
 
 /**
@@ -719,7 +1380,7 @@ func (parser *Parser) parseReferenceIndex(lhs node.ReferenceNode, silent bool) n
 	index = parser.parsePrimary()
 
 	if parser.c != ']' {
-		panic(utils.ParseException("ParseException: Expected ]"))
+		panic(parser.parseError("Expected ], found " + parser.currentToken()))
 	}
 
 	parser.next()
@@ -757,7 +1418,7 @@ func (parser *Parser) parseExpression() node.ExpressionNode {
 	var lhs node.ExpressionNode
 	lhs = parser.parseUnaryExpression()
 
-	return NewOperatorParser().Parse(*parser, lhs, 1)
+	return NewOperatorParser(parser).Parse(lhs, 1)
 }
 
 /**
@@ -781,14 +1442,19 @@ func (parser *Parser) parseUnaryExpression() node.ExpressionNode {
 		return localNode
 	}
 
-	if parser.c == '!' {
-		parser.next()
+	if parser.c == '!' || parser.lookingAtWord(node.WORD_NOT) {
+		if parser.c == '!' {
+			parser.next()
+		} else {
+			parser.skipWord(node.WORD_NOT)
+		}
+
 		localNode = node.NewNotExpressionNode(parser.parseUnaryExpression())
 		parser.skipSpace()
 		return localNode
 	}
 
-	return parser.parsePrimary()
+	return parser.parsePrimaryWithOptionalNull(true)
 }
 
 /**
@@ -819,15 +1485,17 @@ func (parser *Parser) parsePrimaryWithOptionalNull(nullAllowed bool) node.Expres
 		// Velocity does not have a negation operator. If we see '-' it must be the start of a
 		// negative integer literal.
 		parser.next()
-		node = parser.parseIntLiteral("-")
+		node = parser.parseNumberLiteral("-")
 	} else if parser.c == '[' {
 		node = parser.parseListLiteral()
+	} else if parser.c == '{' {
+		node = parser.parseMapLiteral()
 	} else if utils.IsAsciiDigit(parser.c) {
-		node = parser.parseIntLiteral("")
+		node = parser.parseNumberLiteral("")
 	} else if utils.IsAsciiLetter(parser.c) {
 		node = parser.parseBooleanOrNullLiteral(nullAllowed)
 	} else {
-		panic(utils.ParseException("Expected a reference or a literal"))
+		panic(parser.parseError("Expected a reference or a literal"))
 	}
 	parser.skipSpace()
 	return node
@@ -848,28 +1516,73 @@ func (parser *Parser) parsePrimaryWithOptionalNull(nullAllowed bool) node.Expres
  */
 func (parser *Parser) parseListLiteral() node.ExpressionNode {
 	utils.AssertRune(parser.c, '[')
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
 	parser.nextNonSpace()
 
 	if parser.c == ']' {
 		parser.next()
-		return node.NewListLiteralNode(parser.ResourceName, parser.lineNumber(), make([]node.ExpressionNode, 0))
+		return node.NewListLiteralNode(parser.ResourceName, startLine, startColumn, make([]node.ExpressionNode, 0))
 	}
 
 	var first node.ExpressionNode
-	first = parser.parsePrimaryWithOptionalNull(false)
+	first = parser.parsePrimaryWithOptionalNull(true)
 
 	if parser.c == '.' {
 		return parser.parseRangeLiteral(first)
 	}
 
-	return parser.parseRemainderOfListLiteral(first)
+	return parser.parseRemainderOfListLiteral(startLine, startColumn, first)
+}
+
+/**
+ * Parses a map literal.
+ *
+ * <pre>{@code
+ * <map-literal> -> { } | { <map-entry> <remainder-of-map-literal>
+ * <map-entry> -> <primary> : <primary>
+ * <remainder-of-map-literal> -> } | , <map-entry> <remainder-of-map-literal>
+ * }</pre>
+ */
+func (parser *Parser) parseMapLiteral() node.ExpressionNode {
+	utils.AssertRune(parser.c, '{')
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
+	parser.nextNonSpace()
+
+	keys := make([]node.ExpressionNode, 0)
+	values := make([]node.ExpressionNode, 0)
+	if parser.c != '}' {
+		for {
+			keys = append(keys, parser.parsePrimaryWithOptionalNull(false))
+			if parser.c != ':' {
+				panic(parser.parseError("Expected : after key in map literal"))
+			}
+
+			parser.nextNonSpace()
+			values = append(values, parser.parsePrimaryWithOptionalNull(true))
+
+			if parser.c != ',' {
+				break
+			}
+
+			parser.nextNonSpace()
+		}
+
+		if parser.c != '}' {
+			panic(parser.parseError("Expected , or } in map literal"))
+		}
+	}
+
+	parser.next()
+	return node.NewMapLiteralNode(parser.ResourceName, startLine, startColumn, keys, values)
 }
 
 func (parser *Parser) parseRangeLiteral(first node.ExpressionNode) node.ExpressionNode {
 	utils.AssertRune(parser.c, '.')
 	parser.next()
 	if parser.c != '.' {
-		panic(utils.ParseException("Expected two dots (..) not just one"))
+		panic(parser.parseError("Expected two dots (..) not just one"))
 	}
 
 	parser.nextNonSpace()
@@ -878,52 +1591,203 @@ func (parser *Parser) parseRangeLiteral(first node.ExpressionNode) node.Expressi
 	last = parser.parsePrimaryWithOptionalNull(false)
 
 	if parser.c != ']' {
-		panic(utils.ParseException("Expected ] at end of range literal"))
+		panic(parser.parseError("Expected ] at end of range literal"))
 	}
 
 	parser.nextNonSpace()
-	return node.NewRangeLiteralNode(parser.ResourceName, parser.lineNumber(), first, last)
+	return node.NewRangeLiteralNode(parser.ResourceName, first.GetLineNumber(), first.GetColumnNumber(), first, last)
 }
 
-func (parser *Parser) parseRemainderOfListLiteral(first node.ExpressionNode) node.ExpressionNode {
-	return nil
+func (parser *Parser) parseRemainderOfListLiteral(startLine uint, startColumn uint, first node.ExpressionNode) node.ExpressionNode {
+	elements := []node.ExpressionNode{first}
+	for parser.c == ',' {
+		parser.next()
+		elements = append(elements, parser.parsePrimaryWithOptionalNull(true))
+	}
+
+	if parser.c != ']' {
+		panic(parser.parseError("Expected ] at end of list literal"))
+	}
+
+	parser.next()
+	return node.NewListLiteralNode(parser.ResourceName, startLine, startColumn, elements)
 }
 
+/**
+ * Parses a string literal. A quote character inside the literal is written twice, as in
+ * {@code 'don''t'}. A single-quoted literal is taken as it is, while a double-quoted one is
+ * itself parsed as a template, so that references and directives inside it are evaluated
+ * each time the literal is.
+ *
+ * <pre>{@code
+ * <string-literal> -> " <template> " | ' <text> '
+ * }</pre>
+ */
 func (parser *Parser) parseStringLiteral(quote rune, expand bool) node.ExpressionNode {
-	return nil
+	utils.AssertRune(parser.c, quote)
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
+
+	// a double-quoted literal inside an interpolated one, written as ""text"",
+	// is taken as it is
+	if expand && parser.literalQuote == 0 {
+		return parser.parseInterpolatedStringLiteral(startLine, startColumn)
+	}
+
+	parser.next()
+
+	var value strings.Builder
+	for {
+		if parser.c == quote {
+			parser.next()
+			if parser.c != quote {
+				break
+			}
+		} else if parser.c == EOF {
+			panic(parser.parseErrorAt(startLine, startColumn, string(quote), "Unterminated string literal"))
+		}
+
+		value.WriteRune(parser.c)
+		parser.next()
+	}
+
+	return node.NewConstantExpressionNode(parser.ResourceName, startLine, startColumn, value.String())
 }
 
-func (parser *Parser) parseIntLiteral(prefix string) node.ExpressionNode {
+/**
+ * Parses the contents of a double-quoted string literal as a template. The contents are
+ * parsed in place, with the closing quote reading as the end of the file, so that the
+ * nodes inside the literal have their true line and column numbers.
+ */
+func (parser *Parser) parseInterpolatedStringLiteral(startLine uint, startColumn uint) node.ExpressionNode {
+	parser.literalQuote = parser.c
+	parser.next()
+
+	parseResult := parser.parseToStop(isEofNode, "in string literal starting on line "+fmt.Sprint(startLine))
+
+	parser.literalQuote = 0
+	if parser.pointer >= uint(len(parser.Chars)) {
+		panic(parser.parseErrorAt(startLine, startColumn, "\"", "Unterminated string literal"))
+	}
+
+	// step past the closing quote, which was read as EOF
+	parser.c = parser.Chars[parser.pointer]
+	parser.next()
+
+	nodes := parseResult.Nodes
+	if len(nodes) == 0 {
+		return node.NewConstantExpressionNode(parser.ResourceName, startLine, startColumn, "")
+	}
+
+	if len(nodes) == 1 {
+		text, isText := nodes[0].(*node.ConstantExpressionNode)
+		if isText {
+			return node.NewConstantExpressionNode(parser.ResourceName, startLine, startColumn, text.String())
+		}
+	}
+
+	body := node.NewConsNode(parser.ResourceName, startLine, startColumn, nodes)
+	return node.NewStringLiteralNode(parser.ResourceName, startLine, startColumn, body)
+}
+
+/**
+ * Parses a number literal, which is an integer or a floating-point number with a
+ * fractional part, an exponent, or both. The given {@code prefix} is {@code -} for a
+ * negative number.
+ *
+ * <pre>{@code
+ * <number-literal> -> <digits> [ . <digits> ] [ <exponent> ]
+ * <exponent> -> e [ + | - ] <digits> | E [ + | - ] <digits>
+ * }</pre>
+ *
+ * <p>A dot is only part of the number if a digit follows it, so that {@code [1..3]} is
+ * still a range. Integers are {@code int64} values and other numbers {@code float64} values.
+ */
+func (parser *Parser) parseNumberLiteral(prefix string) node.ExpressionNode {
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
+
 	var sb strings.Builder
 	sb.WriteString(prefix)
 
-	for utils.IsAsciiDigit(parser.c) {
+	if !utils.IsAsciiDigit(parser.c) {
+		panic(parser.parseError("Expected a digit in number literal"))
+	}
+
+	parser.parseDigits(&sb)
+
+	isInteger := true
+	if parser.c == '.' && utils.IsAsciiDigit(parser.peek()) {
+		isInteger = false
 		sb.WriteRune(parser.c)
 		parser.next()
+		parser.parseDigits(&sb)
+	}
+
+	if parser.c == 'e' || parser.c == 'E' {
+		isInteger = false
+		sb.WriteRune(parser.c)
+		parser.next()
+
+		if parser.c == '+' || parser.c == '-' {
+			sb.WriteRune(parser.c)
+			parser.next()
+		}
+
+		if !utils.IsAsciiDigit(parser.c) {
+			panic(parser.parseError("Expected a digit in the exponent of number literal"))
+		}
+
+		parser.parseDigits(&sb)
 	}
 
 	str := sb.String()
 
-	return node.NewConstantExpressionNode(parser.ResourceName, parser.lineNumber(), str)
+	var value interface{}
+	var err error
+	if isInteger {
+		value, err = strconv.ParseInt(str, 10, 64)
+	} else {
+		value, err = strconv.ParseFloat(str, 64)
+	}
+
+	if err != nil {
+		panic(parser.parseErrorAt(startLine, startColumn, str, "Invalid number literal: "+str))
+	}
+
+	return node.NewConstantExpressionNode(parser.ResourceName, startLine, startColumn, value)
 }
 
+func (parser *Parser) parseDigits(sb *strings.Builder) {
+	for utils.IsAsciiDigit(parser.c) {
+		sb.WriteRune(parser.c)
+		parser.next()
+	}
+}
+
+/**
+ * Parses one of the literals {@code true} and {@code false}, which are {@code bool} values,
+ * or {@code null}, which is nil and only allowed where {@code nullAllowed} says so.
+ */
 func (parser *Parser) parseBooleanOrNullLiteral(nullAllowed bool) node.ExpressionNode {
+	startLine := parser.lineNumber()
+	startColumn := parser.columnNumber()
 	id := parser.parseId("Identifier without $")
 
-	var value string
+	var value interface{}
 
 	switch id {
 	case "true":
-		value = "true"
+		value = true
 		break
 
 	case "false":
-		value = "false"
+		value = false
 		break
 
 	case "null":
 		if nullAllowed {
-			value = ""
+			value = nil
 			break
 		}
 
@@ -935,15 +1799,15 @@ func (parser *Parser) parseBooleanOrNullLiteral(nullAllowed bool) node.Expressio
 		} else {
 			suffix = ""
 		}
-		panic(utils.ParseException("Identifier must be preceded by $ or be true or false" + suffix + ": " + id))
+		panic(parser.parseErrorAt(startLine, startColumn, id, "Identifier must be preceded by $ or be true or false"+suffix+": "+id))
 	}
 
-	return node.NewConstantExpressionNode(parser.ResourceName, parser.lineNumber(), value)
+	return node.NewConstantExpressionNode(parser.ResourceName, startLine, startColumn, value)
 }
 
 func (parser *Parser) parseId(what string) string {
 	if !utils.IsAsciiLetter(parser.c) {
-		panic(utils.ParseException(what + " should start with an ASCII letter"))
+		panic(parser.parseError(what + " should start with an ASCII letter"))
 	}
 
 	var id strings.Builder
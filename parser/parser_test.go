@@ -0,0 +1,1490 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"sangupta.com/velocity/node"
+	"sangupta.com/velocity/resource"
+	"sangupta.com/velocity/utils"
+)
+
+func parseString(text string) (Template, error) {
+	parser := Parser{
+		Chars:        []rune(text),
+		ResourceName: "test.vm",
+	}
+
+	return parser.Parse()
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	_, err := parseString("Hello\n#if ($a = 1)\n#end")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("Expected a ParseError, got %v", err)
+	}
+
+	if parseError.ResourceName != "test.vm" || parseError.Line != 2 || parseError.Column != 9 {
+		t.Errorf("Wrong position: %s:%d:%d", parseError.ResourceName, parseError.Line, parseError.Column)
+	}
+
+	if parseError.Token != "=" {
+		t.Errorf("Wrong token: %q", parseError.Token)
+	}
+
+	if parseError.Excerpt != "#if ($a = 1)\n        ^" {
+		t.Errorf("Wrong excerpt:\n%s", parseError.Excerpt)
+	}
+}
+
+func TestParseErrorInsteadOfPanic(t *testing.T) {
+	templates := []string{
+		"#end",
+		"#if ($a)",
+		"#foreach ($a on $b)#end",
+		"${a",
+		"#[[ unterminated",
+		"#set ($a = foo)",
+		"#if ($a | $b)#end",
+	}
+
+	for _, text := range templates {
+		_, err := parseString(text)
+
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("Expected a ParseError for %q, got %v", text, err)
+		}
+	}
+}
+
+func TestParseEmptyTemplate(t *testing.T) {
+	template, err := parseString("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{})
+	if err != nil || rendered != "" {
+		t.Errorf("Empty template should render as empty")
+	}
+}
+
+func render(t *testing.T, text string, variables map[string]interface{}) string {
+	template, err := parseString(text)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %q: %v", text, err)
+	}
+
+	rendered, err := template.Execute(variables)
+	if err != nil {
+		t.Fatalf("Unexpected error evaluating %q: %v", text, err)
+	}
+
+	return rendered
+}
+
+func TestTemplateEvaluate(t *testing.T) {
+	template, err := parseString("$calc.divide($a, $b)")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered := template.Evaluate(map[string]interface{}{"calc": testCalculator{}, "a": 6, "b": 3})
+	if rendered != "2" {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	defer func() {
+		var evaluationError *node.EvaluationError
+		if err, ok := recover().(error); !ok || !errors.As(err, &evaluationError) {
+			t.Errorf("Expected Evaluate to panic with an EvaluationError, got %v", err)
+		}
+	}()
+
+	template.Evaluate(map[string]interface{}{"calc": testCalculator{}, "a": 6, "b": 0})
+}
+
+func TestUndefinedReference(t *testing.T) {
+	template, err := parseString("Hello\n  $name and $!other")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = template.Execute(map[string]interface{}{})
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) || evaluationError.ResourceName != "test.vm" || evaluationError.LineNumber != 2 {
+		t.Errorf("Expected an EvaluationError on line 2, got %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"name": "bob"})
+	if err != nil || rendered != "Hello\n  bob and " {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+}
+
+func TestMacroCall(t *testing.T) {
+	variables := map[string]interface{}{
+		"x": "outer",
+		"y": "why",
+	}
+
+	text := "#show($y, 1)|#macro(show $x $n)<$x $n>#end\n#show($x 2)|$x"
+	rendered := render(t, text, variables)
+	if rendered != "<why 1>|<outer 2>|outer" {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	if variables["x"] != "outer" {
+		t.Errorf("Macro parameter leaked into the context: %v", variables["x"])
+	}
+
+	if _, defined := variables["n"]; defined {
+		t.Errorf("Macro parameter n should not remain defined")
+	}
+}
+
+func TestMacroErrors(t *testing.T) {
+	templates := []string{
+		"#undefined($x)",
+		"#macro(m $a $b)#end#m($x)",
+		"#macro(m a)#end",
+	}
+
+	for _, text := range templates {
+		_, err := parseString(text)
+
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("Expected a ParseError for %q, got %v", text, err)
+		}
+	}
+}
+
+func TestParseDirectiveInline(t *testing.T) {
+	loader := resource.NewMapResourceLoader(map[string]string{
+		"header.vm": "Hi $name\n#macro(footer)bye#end",
+	})
+
+	parser := Parser{
+		Chars:        []rune("#parse(\"header.vm\")\n, #footer()"),
+		ResourceName: "main.vm",
+		Loader:       loader,
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"name": "there"})
+	if err != nil || rendered != "Hi there\n, bye" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	parser = Parser{
+		Chars:        []rune("#parse($name)"),
+		ResourceName: "main.vm",
+		Loader:       loader,
+	}
+
+	_, err = parser.Parse()
+	if err == nil {
+		t.Errorf("Expected a ParseError for a variable argument when parsing inline")
+	}
+}
+
+func TestParseDirectiveAtRuntime(t *testing.T) {
+	loader := resource.NewMapResourceLoader(map[string]string{
+		"a.vm": "[a $x]",
+	})
+
+	parser := Parser{
+		Chars:        []rune("#if($show)#parse($file)#end."),
+		ResourceName: "main.vm",
+		Loader:       loader,
+		ParseMode:    PARSE_AT_RUNTIME,
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"show": true, "file": "a.vm", "x": "y"})
+	if err != nil || rendered != "[a y]." {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	rendered, err = template.Execute(map[string]interface{}{"file": "a.vm"})
+	if err != nil || rendered != "." {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	_, err = template.Execute(map[string]interface{}{"show": true, "file": "missing.vm"})
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) || evaluationError.LineNumber != 1 {
+		t.Errorf("Expected an EvaluationError, got %v", err)
+	}
+
+	if !errors.Is(err, resource.ErrResourceNotFound) {
+		t.Errorf("Expected the cause to be a missing resource, got %v", err)
+	}
+}
+
+func TestIncludeDirective(t *testing.T) {
+	loader := resource.NewMapResourceLoader(map[string]string{
+		"legal.txt": "(c) $company",
+		"style.css": "#main { color: red }",
+	})
+
+	parser := Parser{
+		Chars:        []rune("A #include(\"legal.txt\", $css 'legal.txt')\nB\n#include($missing)"),
+		ResourceName: "mail.vm",
+		Loader:       loader,
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	variables := map[string]interface{}{
+		"css":     "style.css",
+		"missing": "style.css",
+	}
+
+	rendered, err := template.Execute(variables)
+	if err != nil || rendered != "A (c) $company#main { color: red }(c) $companyB\n#main { color: red }" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	variables["missing"] = "nowhere.txt"
+	_, err = template.Execute(variables)
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) || evaluationError.ResourceName != "mail.vm" || evaluationError.LineNumber != 3 {
+		t.Errorf("Expected an EvaluationError on line 3, got %v", err)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	variables := map[string]interface{}{
+		"x":     "before",
+		"list":  []int64{10, 20, 30},
+		"map":   map[string]int{"b": 2, "a": 1},
+		"outer": [2]string{"p", "q"},
+	}
+
+	text := "#foreach($x in $list)$foreach.index/$foreach.count:$x#if($foreach.hasNext),#end#end $x"
+	rendered := render(t, text, variables)
+	if rendered != "0/1:10,1/2:20,2/3:30 before" {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	text = "#foreach($x in $map)#if($foreach.first)[#end$x#if($foreach.last)]#end#end"
+	rendered = render(t, text, variables)
+	if rendered != "[12]" {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	text = "#foreach($o in $outer)#foreach($c in $o)$o$c$foreach.parent.count $velocityCount #end#end"
+	rendered = render(t, text, variables)
+	if rendered != "pp1 1 qq2 1 " {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	for _, name := range []string{"o", "foreach", "velocityCount", "velocityHasNext"} {
+		if _, defined := variables[name]; defined {
+			t.Errorf("Loop variable %s should not remain defined", name)
+		}
+	}
+
+	template, _ := parseString("#foreach($x in $number)#end")
+	_, err := template.Execute(map[string]interface{}{"number": 5})
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) {
+		t.Errorf("Expected an EvaluationError, got %v", err)
+	}
+}
+
+func TestForEachElse(t *testing.T) {
+	text := "#foreach($x in $items)$x #else\nnone#end."
+	variables := map[string]interface{}{
+		"items": []string{"a", "b"},
+	}
+
+	rendered := render(t, text, variables)
+	if rendered != "a b ." {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	for _, items := range []interface{}{nil, []string{}, map[string]int{}} {
+		variables["items"] = items
+		rendered = render(t, text, variables)
+		if rendered != "none." {
+			t.Errorf("Wrong rendering for %v: %q", items, rendered)
+		}
+	}
+
+	_, err := parseString("#foreach($x in $items)#elseif($y)#end")
+	if err == nil {
+		t.Errorf("Expected a ParseError for #elseif inside #foreach")
+	}
+}
+
+func TestBreakAndStop(t *testing.T) {
+	variables := map[string]interface{}{
+		"rows":  []string{"a", "b", "c"},
+		"cols":  []int{1, 2, 3},
+		"limit": "b",
+	}
+
+	text := "#foreach($r in $rows)$r#if($r == $limit)#break#end,#end."
+	rendered := render(t, text, variables)
+	if rendered != "a,b." {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	variables["limit"] = 1
+	text = "#foreach($r in $rows)#foreach($c in $cols)$r$c #if($c == $limit)#break($foreach.parent)#end#end#end."
+	rendered = render(t, text, variables)
+	if rendered != "a1 ." {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	text = "#macro(m)#foreach($r in $rows)$r#break($macro)#end!#end[#m()]"
+	rendered = render(t, text, variables)
+	if rendered != "[a]" {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	rendered = render(t, "before #foreach($r in $rows)$r#stop#end after", variables)
+	if rendered != "before a" {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	for _, text := range []string{"#break", "#if($x)#break#end", "#foreach($r in $rows)#else#break#end"} {
+		_, err := parseString(text)
+		if err == nil {
+			t.Errorf("Expected a ParseError for %q", text)
+		}
+	}
+}
+
+func TestDefine(t *testing.T) {
+	variables := map[string]interface{}{
+		"items": []string{"a", "b"},
+		"item":  "z",
+	}
+
+	text := "#define($cell)\n<td>$item</td>#end\n#foreach($item in $items)$cell#end|$cell"
+	rendered := render(t, text, variables)
+	if rendered != "<td>a</td><td>b</td>|<td>z</td>" {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	template, err := parseString("x\n#define($loop)[$loop]#end$loop")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = template.Execute(variables)
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) || evaluationError.LineNumber != 2 {
+		t.Errorf("Expected an EvaluationError for a block that references itself, got %v", err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	variables := map[string]interface{}{
+		"subject": "Order $id #if($urgent)(urgent)#end",
+		"id":      42,
+		"urgent":  true,
+		"nothing": nil,
+	}
+
+	template, err := parseString("[#evaluate($subject)] #evaluate($subject)#evaluate($nothing)")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(variables)
+	if err != nil || rendered != "[Order 42 (urgent)] Order 42 (urgent)" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	variables["subject"] = "Order\n#if($id = 1)#end"
+	_, err = template.Execute(variables)
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) || parseError.ResourceName != EVALUATE_RESOURCE_NAME || parseError.Line != 2 {
+		t.Errorf("Expected a ParseError relative to the snippet, got %v", err)
+	}
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) || evaluationError.ResourceName != "test.vm" || evaluationError.LineNumber != 1 {
+		t.Errorf("Expected an EvaluationError for the directive, got %v", err)
+	}
+
+	variables["subject"] = "Order $undefined"
+	_, err = template.Execute(variables)
+	if !errors.As(err, &evaluationError) || evaluationError.ResourceName != "test.vm" || !strings.Contains(err.Error(), "undefined") {
+		t.Errorf("Expected an EvaluationError for the directive, got %v", err)
+	}
+
+	for index := 0; index < 2*MAX_CACHED_SNIPPETS; index++ {
+		variables["subject"] = fmt.Sprint(index)
+		rendered, err = template.Execute(variables)
+		if err != nil || rendered != fmt.Sprintf("[%d] %d", index, index) {
+			t.Fatalf("Wrong rendering: %q, %v", rendered, err)
+		}
+	}
+
+	if len(template.engine.snippets.entries) != MAX_CACHED_SNIPPETS {
+		t.Errorf("Expected the cache to keep %d snippets, got %d", MAX_CACHED_SNIPPETS, len(template.engine.snippets.entries))
+	}
+}
+
+type upperDirective struct {
+}
+
+func (directive *upperDirective) GetName() string {
+	return "upper"
+}
+
+func (directive *upperDirective) GetKind() node.DirectiveKind {
+	return node.BLOCK_DIRECTIVE
+}
+
+func (directive *upperDirective) CheckArguments(arguments []node.ExpressionNode) error {
+	if len(arguments) != 0 {
+		return errors.New("no arguments expected")
+	}
+
+	return nil
+}
+
+func (directive *upperDirective) Render(context *node.EvaluationContext, output *strings.Builder, arguments []node.ExpressionNode, body node.Node) error {
+	builder := strings.Builder{}
+	body.Render(context, &builder)
+	output.WriteString(strings.ToUpper(builder.String()))
+
+	return nil
+}
+
+type repeatDirective struct {
+}
+
+func (directive *repeatDirective) GetName() string {
+	return "repeat"
+}
+
+func (directive *repeatDirective) GetKind() node.DirectiveKind {
+	return node.LINE_DIRECTIVE
+}
+
+func (directive *repeatDirective) CheckArguments(arguments []node.ExpressionNode) error {
+	if len(arguments) != 2 {
+		return errors.New("expected a count and a value")
+	}
+
+	return nil
+}
+
+func (directive *repeatDirective) Render(context *node.EvaluationContext, output *strings.Builder, arguments []node.ExpressionNode, body node.Node) error {
+	count, ok := arguments[0].Evaluate(context).(int)
+	if !ok {
+		return errors.New("count must be an int")
+	}
+
+	output.WriteString(strings.Repeat(fmt.Sprint(arguments[1].Evaluate(context)), count))
+	return nil
+}
+
+func TestCustomDirectives(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("#upper\nhi $name #repeat($n, '-')#end\n#repeat ($n $name) #upper is"),
+		ResourceName: "custom.vm",
+	}
+
+	if parser.RegisterDirective(&upperDirective{}) != nil || parser.RegisterDirective(&repeatDirective{}) != nil {
+		t.Fatalf("Cannot register directives")
+	}
+
+	template, err := parser.Parse()
+	if err == nil {
+		t.Fatalf("Expected a ParseError for a block directive without #end")
+	}
+
+	parser.Chars = []rune("#upper\nhi $name #repeat($n, '-')#end\n#repeat ($n $name) #repeat is")
+	template, err = parser.Parse()
+	if err == nil {
+		t.Fatalf("Expected a ParseError for wrong arguments")
+	}
+
+	parser.Chars = []rune("#upper\nhi $name #repeat($n, '-')#end\n#repeat ($n $name) #{upper}is#end")
+	template, err = parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"name": "bob", "n": 2})
+	if err != nil || rendered != "HI BOB --bobbob IS" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	_, err = template.Execute(map[string]interface{}{"name": "bob", "n": "two"})
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) || evaluationError.LineNumber != 2 {
+		t.Errorf("Expected an EvaluationError, got %v", err)
+	}
+
+	if parser.RegisterDirective(&namedDirective{name: "foreach"}) == nil {
+		t.Errorf("Standard directives should not be replaceable")
+	}
+}
+
+type namedDirective struct {
+	upperDirective
+	name string
+}
+
+func (directive *namedDirective) GetName() string {
+	return directive.name
+}
+
+func TestStringLiterals(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("#macro(show $s)[$s]#end#show(\"Hi $name, \"\"#if($name)yes#end\"\"\")#show('it''s $name')#show(\"\")#show(\"plain\")"),
+		ResourceName: "strings.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"name": "bob"})
+	if err != nil || rendered != "[Hi bob, \"yes\"][it's $name][][plain]" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	parser.Chars = []rune("#show(\"one\ntwo #end\")")
+	_, err = parser.Parse()
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) || parseError.Line != 2 || parseError.Column != 9 {
+		t.Errorf("Expected a ParseError on line 2, column 9, got %v", err)
+	}
+
+	parser.Chars = []rune("#show(\"one $name)")
+	_, err = parser.Parse()
+	if !errors.As(err, &parseError) || parseError.Line != 1 || parseError.Column != 7 {
+		t.Errorf("Expected a ParseError for the unterminated literal, got %v", err)
+	}
+
+	// a reference followed by a dot that ends the literal
+	rendered = render(t, "#set($a = \"Hello $name.\")[$a]#set($a = \"$name.\")[$a]#set($a = \"Hello $name.length().\")[$a]", map[string]interface{}{"name": "bob"})
+	if rendered != "[Hello bob.][bob.][Hello 3.]" {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+}
+
+type testAddress struct {
+	City string
+}
+
+type testPerson struct {
+	*testAddress
+	Name  string
+	email string
+}
+
+func (person testPerson) GetEmail() string {
+	return person.email
+}
+
+func (person *testPerson) IsAdmin() bool {
+	return person.Name == "root"
+}
+
+func (person *testPerson) Phone() (string, error) {
+	return "", errors.New("no phone")
+}
+
+func TestMemberReferences(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("$order.customer.name $order.customer.email $order.customer.admin $order.customer.city $order.lines.qty $order.id $!order.owner.name $!order.missing.name $!nobody.name"),
+		ResourceName: "members.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	customer := testPerson{
+		testAddress: &testAddress{City: "Paris"},
+		Name:        "root",
+		email:       "root@example.com",
+	}
+
+	var owner *testPerson
+	order := map[string]interface{}{
+		"customer": customer,
+		"lines":    map[string]interface{}{"qty": 3},
+		"id":       "A1",
+		"owner":    owner,
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"order": &order})
+	if err != nil || rendered != "root root@example.com true Paris 3 A1   " {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	customer.testAddress = nil
+	customer.Name = "bob"
+	order["customer"] = &customer
+	parser.Chars = []rune("$order.customer.admin$!order.customer.city")
+	template, _ = parser.Parse()
+	rendered, err = template.Execute(map[string]interface{}{"order": order})
+	if err != nil || rendered != "false" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	for _, text := range []string{"$order.customer.phone", "$order.customer.email.size", "$order.owner.name"} {
+		parser.Chars = []rune(text)
+		template, _ = parser.Parse()
+
+		var evaluationError *node.EvaluationError
+		_, err = template.Execute(map[string]interface{}{"order": order})
+		if !errors.As(err, &evaluationError) {
+			t.Errorf("Expected an EvaluationError for %s, got %v", text, err)
+		}
+	}
+}
+
+type testCalculator struct {
+	Base int
+}
+
+func (calculator *testCalculator) Add(a int64, b float64) float64 {
+	return float64(calculator.Base) + float64(a) + b
+}
+
+func (calculator testCalculator) Join(separator string, parts ...string) string {
+	return strings.Join(parts, separator)
+}
+
+func (calculator testCalculator) Repeat(char rune, count uint8) string {
+	return strings.Repeat(string(char), int(count))
+}
+
+func (calculator testCalculator) Kind(value interface{}) string {
+	return fmt.Sprintf("%T", value)
+}
+
+func (calculator testCalculator) Kinds(values []interface{}) string {
+	kinds := make([]string, len(values))
+	for index, value := range values {
+		kinds[index] = fmt.Sprintf("%T", value)
+	}
+
+	return strings.Join(kinds, " ")
+}
+
+func (calculator testCalculator) Sum(numbers []interface{}) int64 {
+	var sum int64
+	for _, number := range numbers {
+		sum += number.(int64)
+	}
+
+	return sum
+}
+
+func (calculator testCalculator) Describe(person *testPerson) string {
+	if person == nil {
+		return "nobody"
+	}
+
+	return person.Name
+}
+
+func (calculator testCalculator) Divide(a int, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("division by zero")
+	}
+
+	return a / b, nil
+}
+
+func TestMethodReferences(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("$calc.Add($one, $half) $calc.join('-', 'a', $name) $calc.join('-') $calc.repeat('x', $one) $calc.describe($none) $calc.divide($one, $one)#foreach($i in $list)$foreach.hasNext()#end"),
+		ResourceName: "methods.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	variables := map[string]interface{}{
+		"calc": testCalculator{Base: 10},
+		"one":  1,
+		"half": float32(0.5),
+		"name": "b",
+		"none": nil,
+		"list": []int{1, 2},
+	}
+
+	rendered, err := template.Execute(variables)
+	if err != nil || rendered != "11.5 a-b  x nobody 1truefalse" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	variables["zero"] = 0
+	variables["minus"] = -1
+	for _, text := range []string{"$calc.divide($one,\n $zero)", "$calc.repeat('xy', $one)", "$calc.repeat('x', $minus)", "$calc.add($one)", "$calc.missing()"} {
+		parser.Chars = []rune(text)
+		template, err = parser.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", text, err)
+		}
+
+		var evaluationError *node.EvaluationError
+		_, err = template.Execute(variables)
+		if !errors.As(err, &evaluationError) || evaluationError.ResourceName != "methods.vm" || evaluationError.LineNumber != 1 {
+			t.Errorf("Expected an EvaluationError for %s, got %v", text, err)
+		}
+	}
+}
+
+func TestJavaMethods(t *testing.T) {
+	parser := Parser{
+		Chars: []rune("$name.length() $name.toUpperCase() $name.substring($one, $three) $name.charAt($zero) $name.indexOf('é') $name.replaceAll('(\\w)l', '$1L') $name.split('l').size() " +
+			"$list.size() $list.get($one) $list.contains($three) $list.indexOf($two) " +
+			"$map.keySet() $map.containsKey('b') $!map.get('z') $map.getOrDefault('z', 'none')#foreach($e in $map.entrySet()) $e.key=$e.value#end $one.toString() " +
+			"$one.equals($real) $real.equals($one) $one.equals($half) $half.equals($one) $list.contains($real) $two.equals($big) $big.equals($two)"),
+		ResourceName: "java.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	variables := map[string]interface{}{
+		"name":  "héllo",
+		"list":  []int64{1, 2, 3},
+		"map":   map[string]int{"b": 2, "a": 1},
+		"zero":  0,
+		"one":   1,
+		"two":   2,
+		"three": 3,
+		"real":  1.0,
+		"half":  1.5,
+		"big":   uint64(math.MaxUint64),
+	}
+
+	rendered, err := template.Execute(variables)
+	expected := "5 HÉLLO él h 1 hélLo 3 3 2 true 1 [a, b] true  none a=1 b=2 1 true true false false true false false"
+	if err != nil || rendered != expected {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	for _, text := range []string{"$name.substring($three, $one)", "$list.get($three)", "$name.length($one)", "$name.size()"} {
+		parser.Chars = []rune(text)
+		template, err = parser.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", text, err)
+		}
+
+		var evaluationError *node.EvaluationError
+		_, err = template.Execute(variables)
+		if !errors.As(err, &evaluationError) {
+			t.Errorf("Expected an EvaluationError for %s, got %v", text, err)
+		}
+	}
+}
+
+type testBook struct {
+	Title  string
+	Active bool
+}
+
+func (book *testBook) GetTitle() string {
+	return "The " + book.Title
+}
+
+func (book *testBook) IsPrinted() string {
+	return "yes"
+}
+
+func TestJavaBeanAccessors(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("$user.getName() $user.name $user.isAdmin() $user.admin $user.getEmail() $user.email $book.title $book.getTitle() $book.isActive()$!user.setName($new) $user.getName()"),
+		ResourceName: "beans.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	user := &testPerson{Name: "root", email: "root@example.com"}
+	variables := map[string]interface{}{
+		"user": user,
+		"book": testBook{Title: "Hobbit", Active: true},
+		"new":  "alice",
+	}
+
+	rendered, err := template.Execute(variables)
+	expected := "root root true true root@example.com root@example.com Hobbit The Hobbit true alice"
+	if err != nil || rendered != expected {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	for _, text := range []string{"$!book.setTitle($new)", "$!user.setName($book)", "$!user.setEmail($new)", "$book.printed"} {
+		parser.Chars = []rune(text)
+		template, err = parser.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", text, err)
+		}
+
+		var evaluationError *node.EvaluationError
+		_, err = template.Execute(variables)
+		if !errors.As(err, &evaluationError) {
+			t.Errorf("Expected an EvaluationError for %s, got %v", text, err)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("#set($t = $a + $b * $c)[$t]#set($t = $a / $b)[$t]#set($t = $a % $b)[$t]#set($t = $f * $a)[$t]#set($t = $h + $h)[$t]#set($t = $u - $v)[$t]#set($t = $v - $u)[$t]#set($t = $s + $a)[$t]#if($a - $a)yes#end"),
+		ResourceName: "arithmetic.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	variables := map[string]interface{}{
+		"a": 7,
+		"b": int8(2),
+		"c": uint16(3),
+		"f": 0.5,
+		"h": float32(1.25),
+		"u": uint(3),
+		"v": uint8(5),
+		"s": "x",
+	}
+
+	rendered, err := template.Execute(variables)
+	if err != nil || rendered != "[13][3][1][3.5][2.5][-2][2][x7]yes" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	variables["max"] = int64(math.MaxInt64)
+	variables["zero"] = 0
+	tests := map[string]error{
+		"$max + $a":  utils.ErrOverflow,
+		"$max * $b":  utils.ErrOverflow,
+		"$a / $zero": utils.ErrDivisionByZero,
+		"$f % $zero": utils.ErrDivisionByZero,
+	}
+
+	for text, expected := range tests {
+		parser.Chars = []rune("#set($t = " + text + ")")
+		template, err = parser.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", text, err)
+		}
+
+		_, err = template.Execute(variables)
+		if !errors.Is(err, expected) {
+			t.Errorf("Expected %v for %s, got %v", expected, text, err)
+		}
+	}
+
+	parser.Chars = []rune("#set($t = $a * $s)")
+	template, _ = parser.Parse()
+	_, err = template.Execute(variables)
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) {
+		t.Errorf("Expected an EvaluationError, got %v", err)
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("#set($rate = 0.25)#set($t = $rate * 4)[$t]#set($t = 1e3)[$t]#set($t = 2.5E-1 + 1)[$t]#set($t = 7 / 2)[$t]#set($t = -3 % 2)[$t]#set($t = $n + 0.5)[$t]#set($t = 1e7)[$t]#set($t = 1.5e-4)[$t]#set($t = $f * 2)[$t]#set($r = [1..3])"),
+		ResourceName: "numbers.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// floating-point numbers render as in Java
+	rendered, err := template.Execute(map[string]interface{}{"n": 2, "f": float32(0.1)})
+	if err != nil || rendered != "[1.0][1000.0][1.25][3][-1][2.5][1.0E7][1.5E-4][0.2]" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	for _, text := range []string{"#set($x = 1e)", "#set($x = 1.5e+x)", "#set($x = 99999999999999999999)", "#set($x = 1e999)", "#set($x = -)"} {
+		parser.Chars = []rune(text)
+		_, err = parser.Parse()
+
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("Expected a ParseError for %s, got %v", text, err)
+		}
+	}
+}
+
+func TestTypedLiterals(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("#if(false)no#else[yes]#end#set($b = true)#if($b)[$b]#end#if(!false)[not]#end#set($n = null)[$!n]#set($x = 5)#set($y = $x * 2)[$y]#set($s = 'a' + true + null)[$s][$calc.describe(null)]"),
+		ResourceName: "literals.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"calc": testCalculator{}})
+	if err != nil || rendered != "[yes][true][not][][10][atruenull][nobody]" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	parser.Chars = []rune("#set($x = nothing)")
+	_, err = parser.Parse()
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Errorf("Expected a ParseError, got %v", err)
+	}
+}
+
+type testVersion struct {
+	major int
+}
+
+func (version testVersion) CompareTo(other interface{}) (int, bool) {
+	otherVersion, ok := other.(testVersion)
+	if !ok {
+		return 0, false
+	}
+
+	return version.major - otherVersion.major, true
+}
+
+func TestRelationalOperators(t *testing.T) {
+	parser := Parser{
+		Chars: []rune("#if($small < $big)a#end#if($big <= 300)b#end#if($neg > $unsigned)x#else c#end#if($f >= 1.5)d#end" +
+			"#if('apple' < 'banana')e#end#if($early < $late)f#end#if($v1 < $v2)g#end#if(2 > 1 && 1 >= 1)h#end" +
+			"#if($nan > $big)i#end#if($nan < 1)x#end#if($nan >= $nan)j#end"),
+		ResourceName: "relational.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	variables := map[string]interface{}{
+		"small":    int8(-3),
+		"big":      uint64(300),
+		"neg":      -1,
+		"unsigned": uint(1),
+		"f":        float32(1.5),
+		"early":    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		"late":     time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		"v1":       testVersion{1},
+		"v2":       testVersion{2},
+		"nan":      math.NaN(),
+	}
+
+	rendered, err := template.Execute(variables)
+	if err != nil || rendered != "ab cdefghij" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	for _, text := range []string{"#if($v1 < 3)#end", "#if('a' < 1)#end", "#if($early > null)#end"} {
+		parser.Chars = []rune(text)
+		template, err = parser.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", text, err)
+		}
+
+		var evaluationError *node.EvaluationError
+		_, err = template.Execute(variables)
+		if !errors.As(err, &evaluationError) || !strings.Contains(err.Error(), "Cannot compare") {
+			t.Errorf("Expected an EvaluationError for %s, got %v", text, err)
+		}
+	}
+}
+
+func TestEquality(t *testing.T) {
+	parser := Parser{
+		Chars: []rune("#if($n == '123')a#end#if($n == $n64)b#end#if($n == 123.0)c#end#if($none == null)d#end#if($owner == null)e#end#if($n != null)f#end" +
+			"#if($list == $same)g#end#if($list != $other)h#end#if($map == $map)i#end#if(true == 'true')j#end#if($n == 124)x#end#if($list == 'x')x#end" +
+			"#if($nan == $nan)x#end#if($nan != $nan)k#end#if($nan == 1.0)x#end#if($nan != 1)l#end"),
+		ResourceName: "equality.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var owner *testPerson
+	variables := map[string]interface{}{
+		"n":     123,
+		"n64":   int64(123),
+		"none":  nil,
+		"owner": owner,
+		"list":  []int{1, 2},
+		"same":  []int{1, 2},
+		"other": []int{2, 1},
+		"map":   map[string]int{"a": 1},
+		"nan":   math.NaN(),
+	}
+
+	rendered, err := template.Execute(variables)
+	if err != nil || rendered != "abcdefghijkl" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+}
+
+func TestWordOperators(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("#if($a eq 1 and not $b)a#end#if($a ne 2 or $b)b#end#if($a lt 2 and $a le 1 and $a gt 0 and $a ge 1)c#end#if(not($a gt 1))d#end#if($b or $nothing)x#end#if($a eq $equals)e#end#set($notes = 'n')#if(!$b and $notes eq 'n')f#end"),
+		ResourceName: "words.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"a": 1, "b": false, "equals": 1})
+	if err != nil || rendered != "abcdef" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	for _, text := range []string{"#if($a equals 1)#end", "#if($a andy 1)#end", "#if(note)#end"} {
+		parser.Chars = []rune(text)
+		_, err = parser.Parse()
+
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("Expected a ParseError for %s, got %v", text, err)
+		}
+	}
+}
+
+func TestMapLiterals(t *testing.T) {
+	parser := Parser{
+		Chars: []rune("#set($m = {\"z\": 1, 'b': $x, 3: 'three', \"z\": 26})#foreach($v in $m)[$v]#end $m.b $m[\"z\"] $m[$three] $m.size() $m.keySet() $m " +
+			"#set($e = {})$e.isEmpty()#set($ignored = $m.put('new', true))$m.containsKey('new')$!m.missing $list[1] $gomap['k'] $gomap $nested [$m]"),
+		ResourceName: "maps.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	variables := map[string]interface{}{
+		"x":      "ex",
+		"three":  uint8(3),
+		"list":   []string{"a", "b"},
+		"gomap":  map[string]int{"k": 7},
+		"anymap": map[interface{}]int{},
+		"nested": &map[string]interface{}{"b": []int{1}, "a": map[int]string{10: "ten", 2: "two"}},
+	}
+
+	rendered, err := template.Execute(variables)
+	expected := "[26][ex][three] ex 26 three 3 [z, b, 3] {z=26, b=ex, 3=three}truetrue b 7 {k=7} {a={2=two, 10=ten}, b=[1]} [{z=26, b=ex, 3=three, new=true}]"
+	if err != nil || rendered != expected {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	for _, text := range []string{"#set($m = {'a' 1})", "#set($m = {'a': 1"} {
+		parser.Chars = []rune(text)
+		_, err = parser.Parse()
+
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("Expected a ParseError for %s, got %v", text, err)
+		}
+	}
+
+	keys := []string{
+		"#set($m = {$list: 1})",
+		"#set($m = {})#set($ignored = $m.put($list, 1))",
+		"#set($m = {})#set($m[$list] = 1)",
+		"#set($m = {})#set($ignored = $m.get($list))",
+		"#set($m = {})#set($ignored = $m.containsKey($list))",
+		"#set($ignored = $anymap.put($list, 1))",
+	}
+
+	for _, text := range keys {
+		parser.Chars = []rune(text)
+		template, err = parser.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", text, err)
+		}
+
+		_, err = template.Execute(variables)
+
+		var evaluationError *node.EvaluationError
+		if !errors.As(err, &evaluationError) {
+			t.Errorf("Expected an EvaluationError for a key that is not comparable in %s, got %v", text, err)
+		}
+	}
+}
+
+func TestListLiterals(t *testing.T) {
+	parser := Parser{
+		Chars: []rune("#set($l = ['a', $x, [1, 2], {'k': null}, null])$l.size() $l[1] $l[2][1] $l#foreach($e in $l[2])[$e]#end" +
+			"#foreach($i in [1, 2])#set($fresh = [])#set($ignored = $fresh.add($i))$fresh.size()#end #set($ignored = $l.add(0, 'first'))$l[0] $l.set(1, 'A') $l[1] $calc.join('-', 'x', 'y') $calc.sum([1, 2, 3]) $e.isEmpty()"),
+		ResourceName: "lists.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	variables := map[string]interface{}{
+		"x":    "ex",
+		"calc": testCalculator{},
+		"e":    []int{},
+	}
+
+	rendered, err := template.Execute(variables)
+	expected := "5 ex 2 [a, ex, [1, 2], {k=null}, null][1][2]11first a A x-y 6 true"
+	if err != nil || rendered != expected {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	for _, text := range []string{"#set($l = ['a' 'b'])", "#set($l = [1, 2)"} {
+		parser.Chars = []rune(text)
+		_, err = parser.Parse()
+
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("Expected a ParseError for %s, got %v", text, err)
+		}
+	}
+
+	parser.Chars = []rune("#set($ignored = $e.add(1))#set($ignored = $e.subList(0, 1).add(2))")
+	template, _ = parser.Parse()
+	_, err = template.Execute(variables)
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) {
+		t.Errorf("Expected an EvaluationError when adding to a slice that cannot be assigned back, got %v", err)
+	}
+
+	if fmt.Sprint(variables["e"]) != "[1]" {
+		t.Errorf("Expected adding to a variable to assign the grown slice to it, got %v", variables["e"])
+	}
+
+	// Go code is given the slices, which grow by being assigned back to where they were found
+	gomap := map[string]interface{}{}
+	variables["gomap"] = gomap
+	parser.Chars = []rune("#set($gomap.k = [1, 2])#set($l = [])$calc.kind([1]) $calc.kind($l) $calc.kinds([[1, 2], 'a'])" +
+		"#set($m = {'items': [[]]})#set($ignored = $m.items.add(1))#set($ignored = $m.items[0].add(2))#set($alias = $m.items)#set($ignored = $alias.add(3)) $m.items $alias")
+	template, _ = parser.Parse()
+	rendered, err = template.Execute(variables)
+	if err != nil || rendered != "[]interface {} []interface {} []interface {} string [[2], 1] [[2], 1, 3]" {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	if _, ok := gomap["k"].([]interface{}); !ok {
+		t.Errorf("Expected a list put in a Go map to be a slice, got %T", gomap["k"])
+	}
+
+	for _, text := range []string{"#set($m = {[1]: 2})", "#set($m = {})#set($ignored = $m.get([1]))"} {
+		parser.Chars = []rune(text)
+		template, _ = parser.Parse()
+		_, err = template.Execute(variables)
+		if !errors.As(err, &evaluationError) {
+			t.Errorf("Expected an EvaluationError for a list used as a key in %s, got %v", text, err)
+		}
+	}
+}
+
+func TestRangeLiterals(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("#foreach($i in [1..3])$i#end #foreach($i in [$n..1])$i#end #foreach($i in [-1..-1])$i#end #set($r = [2..$n])$r $r.size() $r[1] $r.contains(3)"),
+		ResourceName: "ranges.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"n": 4})
+	expected := "123 4321 -1[2, 3, 4] 3 3 true"
+	if err != nil || rendered != expected {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	for _, text := range []string{"#foreach($i in ['a'..2])$i#end", "#set($r = [1..2.5])", "#set($r = [1..$s])"} {
+		parser.Chars = []rune(text)
+		template, err = parser.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", text, err)
+		}
+
+		_, err = template.Execute(map[string]interface{}{"s": "x"})
+
+		var evaluationError *node.EvaluationError
+		if !errors.As(err, &evaluationError) || !strings.Contains(err.Error(), "Range bound must be an integer") {
+			t.Errorf("Expected an EvaluationError for %s, got %v", text, err)
+		}
+	}
+
+	for _, text := range []string{"#set($r = [$min..$max])", "#set($r = [$max..$min])"} {
+		parser.Chars = []rune(text)
+		template, err = parser.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", text, err)
+		}
+
+		_, err = template.Execute(map[string]interface{}{"min": int64(math.MinInt64), "max": int64(math.MaxInt64)})
+
+		var evaluationError *node.EvaluationError
+		if !errors.As(err, &evaluationError) || !strings.Contains(err.Error(), "elements") {
+			t.Errorf("Expected an EvaluationError for the size of %s, got %v", text, err)
+		}
+	}
+}
+
+func TestSetTargets(t *testing.T) {
+	parser := Parser{
+		Chars: []rune("#set($l = [1, 2, 3])#set($l[1] = 'two')#set($m = {'a': 1})#set($m['b'] = 2)#set($m.c = 3)#set($list[0] = 'A')" +
+			"#set($counts.x = 5)#set($counts['y'] = 6)#set($user.name = 'new')#set($user.city = 'Rome')$l $m $list $counts.x $counts.y $user.name $user.city"),
+		ResourceName: "targets.vm",
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	variables := map[string]interface{}{
+		"list":   []interface{}{"a", "b"},
+		"counts": map[string]int{"x": 1},
+		"user":   &testPerson{Name: "old", testAddress: &testAddress{City: "Paris"}},
+		"value":  testPerson{Name: "value"},
+		"array":  [2]int{1, 2},
+		"str":    "abc",
+	}
+
+	rendered, err := template.Execute(variables)
+	expected := "[1, two, 3] {a=1, b=2, c=3} [A, b] 5 6 new Rome"
+	if err != nil || rendered != expected {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+
+	texts := []string{
+		"#set($l = [1])#set($l[1] = 0)",
+		"#set($l = [1])#set($l[-1] = 0)",
+		"#set($value.name = 'x')",
+		"#set($array[0] = 5)",
+		"#set($str[0] = 'x')",
+		"#set($user.missing = 1)",
+		"#set($counts.x = 'five')",
+	}
+
+	for _, text := range texts {
+		parser.Chars = []rune(text)
+		template, err = parser.Parse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", text, err)
+		}
+
+		_, err = template.Execute(variables)
+
+		var evaluationError *node.EvaluationError
+		if !errors.As(err, &evaluationError) {
+			t.Errorf("Expected an EvaluationError for %s, got %v", text, err)
+		}
+	}
+
+	parser.Chars = []rune(texts[0])
+	template, _ = parser.Parse()
+	_, err = template.Execute(variables)
+	if err == nil || !strings.Contains(err.Error(), "index 1 out of bounds for length 1") {
+		t.Errorf("Expected an out of bounds error, got %v", err)
+	}
+
+	parser.Chars = []rune("#set($l.size() = 1)")
+	_, err = parser.Parse()
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Errorf("Expected a ParseError for a method call target, got %v", err)
+	}
+}
+
+func TestIfDirective(t *testing.T) {
+	text := "#if($a)A#elseif($b && !$c)B#{else}C#end|#if($a || $b)D#end"
+
+	cases := []struct {
+		variables map[string]interface{}
+		expected  string
+	}{
+		{map[string]interface{}{"a": true}, "A|D"},
+		{map[string]interface{}{"b": true}, "B|D"},
+		{map[string]interface{}{"b": true, "c": true}, "C|D"},
+		{map[string]interface{}{}, "C|"},
+	}
+
+	for _, testCase := range cases {
+		rendered := render(t, text, testCase.variables)
+		if rendered != testCase.expected {
+			t.Errorf("Wrong rendering for %v: %q", testCase.variables, rendered)
+		}
+	}
+}
+
+func TestSetDirective(t *testing.T) {
+	variables := map[string]interface{}{
+		"y": "why",
+	}
+
+	rendered := render(t, "#set($x = $y)[$x]#set($s = 'it''s')[$s]#set($x = $s)[$x]", variables)
+	if rendered != "[why][it's][it's]" {
+		t.Errorf("Wrong rendering: %q", rendered)
+	}
+
+	if variables["x"] != "it's" || variables["s"] != "it's" {
+		t.Errorf("#set should assign to the variables given to Execute: %v", variables)
+	}
+}
+
+func TestParserReuse(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("#macro(m)A#end#m()"),
+		ResourceName: "first.vm",
+	}
+
+	first, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	parser.Chars = []rune("#macro(m)B#end#m()")
+	second, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for template, expected := range map[*Template]string{&first: "A", &second: "B"} {
+		rendered, err := template.Execute(nil)
+		if err != nil || rendered != expected {
+			t.Errorf("Wrong rendering: %q, %v, expected %q", rendered, err, expected)
+		}
+	}
+
+	parser.Chars = []rune("#m()")
+	_, err = parser.Parse()
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Errorf("Expected a ParseError for a macro defined by an earlier template, got %v", err)
+	}
+
+	parser = Parser{
+		Chars:        []rune("#parse($file)"),
+		ResourceName: "main.vm",
+		Loader:       resource.NewMapResourceLoader(map[string]string{"a.vm": "#upper a#end"}),
+		ParseMode:    PARSE_AT_RUNTIME,
+	}
+
+	if parser.RegisterDirective(&upperDirective{}) != nil {
+		t.Fatalf("Cannot register directive")
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	parser.Loader = resource.NewMapResourceLoader(map[string]string{"a.vm": "#upper b#end"})
+	parser.ParseMode = PARSE_INLINE
+	delete(parser.Directives, "upper")
+
+	rendered, err := template.Execute(map[string]interface{}{"file": "a.vm"})
+	if err != nil || rendered != " A" {
+		t.Errorf("Wrong rendering after changing the parser: %q, %v", rendered, err)
+	}
+}
+
+func TestBreakInParsedResource(t *testing.T) {
+	parser := Parser{
+		Chars:        []rune("#foreach($r in $rows)$r#parse('stop.vm'),#end.#evaluate('#break')"),
+		ResourceName: "main.vm",
+		Loader:       resource.NewMapResourceLoader(map[string]string{"stop.vm": "#if($r == 'b')#break#end"}),
+		ParseMode:    PARSE_AT_RUNTIME,
+	}
+
+	template, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err := template.Execute(map[string]interface{}{"rows": []string{"a", "b", "c"}})
+
+	var evaluationError *node.EvaluationError
+	if !errors.As(err, &evaluationError) || !strings.Contains(err.Error(), "#break is only allowed inside #foreach or #macro") {
+		t.Errorf("Expected an EvaluationError for #break outside any scope, got %q, %v", rendered, err)
+	}
+
+	parser.Chars = []rune("#foreach($r in $rows)$r#parse('stop.vm'),#end.")
+	template, err = parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rendered, err = template.Execute(map[string]interface{}{"rows": []string{"a", "b", "c"}})
+	if err != nil || rendered != "a,b." {
+		t.Errorf("Wrong rendering: %q, %v", rendered, err)
+	}
+}
@@ -0,0 +1,72 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package parser
+
+import (
+	"container/list"
+
+	"sangupta.com/velocity/node"
+)
+
+/**
+ * How many of the strings given to {@code #evaluate} an engine keeps parsed. When there
+ * are more, the least recently used one is dropped and parsed again if it is needed.
+ */
+const MAX_CACHED_SNIPPETS = 100
+
+/**
+ * The parsed strings given to {@code #evaluate}, keyed by their text, of which at most
+ * `MAX_CACHED_SNIPPETS` are kept. The cache is not safe for concurrent use.
+ */
+type snippetCache struct {
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type snippet struct {
+	text   string
+	parsed node.Node
+}
+
+func (cache *snippetCache) get(text string) (node.Node, bool) {
+	element, ok := cache.entries[text]
+	if !ok {
+		return nil, false
+	}
+
+	cache.order.MoveToFront(element)
+	return element.Value.(*snippet).parsed, true
+}
+
+func (cache *snippetCache) put(text string, parsed node.Node) {
+	element, ok := cache.entries[text]
+	if ok {
+		element.Value.(*snippet).parsed = parsed
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[text] = cache.order.PushFront(&snippet{text: text, parsed: parsed})
+
+	if cache.order.Len() > MAX_CACHED_SNIPPETS {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*snippet).text)
+	}
+}
+
+func newSnippetCache() *snippetCache {
+	return &snippetCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
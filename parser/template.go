@@ -12,6 +12,7 @@
 package parser
 
 import (
+	"runtime"
 	"strings"
 
 	"sangupta.com/velocity/node"
@@ -19,21 +20,70 @@ import (
 
 type Template struct {
 	Root   node.Node
-	Macros map[string]Macro
+	Macros map[string]*Macro
 	Type   string
+	engine *engine
 }
 
 /**
  * Evaluate this template against the given set of
- * variable data.
+ * variable data. If evaluation fails, this panics
+ * with the error; use `Execute` to have it returned
+ * instead.
  */
 func (template *Template) Evaluate(variables map[string]interface{}) string {
+	rendered, err := template.Execute(variables)
+	if err != nil {
+		panic(err)
+	}
+
+	return rendered
+}
+
+/**
+ * Evaluate this template against the given set of
+ * variable data. If evaluation fails, the error is
+ * returned, usually as a `*node.EvaluationError`.
+ * A {@code #stop} ends the evaluation, keeping what
+ * was rendered before it.
+ */
+func (template *Template) Execute(variables map[string]interface{}) (rendered string, err error) {
 	builder := strings.Builder{}
+
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		switch value := recovered.(type) {
+		case *node.StopSignal:
+			rendered = builder.String()
+
+		case runtime.Error:
+			panic(value)
+
+		case error:
+			err = value
+
+		default:
+			panic(recovered)
+		}
+	}()
+
+	if variables == nil {
+		variables = make(map[string]interface{})
+	}
+
 	context := node.EvaluationContext{
 		Variables: variables,
 	}
 
+	if template.engine != nil {
+		context.Runtime = template.engine
+	}
+
 	template.Root.Render(&context, &builder)
 
-	return builder.String()
+	return builder.String(), nil
 }
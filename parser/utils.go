@@ -18,6 +18,26 @@ import "sangupta.com/velocity/node"
  */
 const EOF = -1
 
+/**
+ * The directives that the parser handles itself, whose names cannot be
+ * used by a `node.Directive`.
+ */
+var standardDirectives = map[string]bool{
+	"end":      true,
+	"if":       true,
+	"elseif":   true,
+	"else":     true,
+	"foreach":  true,
+	"set":      true,
+	"parse":    true,
+	"include":  true,
+	"break":    true,
+	"stop":     true,
+	"macro":    true,
+	"define":   true,
+	"evaluate": true,
+}
+
 /**
  * Check if node is a type of `node.EofNode`
  */
@@ -72,6 +92,19 @@ func isElseOrElseIfEndNode(localNode node.Node) bool {
 	return ok
 }
 
+/**
+ * Check if node is a type of `node.ElseNode` or `node.EndNode`
+ */
+func isElseOrEndNode(localNode node.Node) bool {
+	_, ok := localNode.(*node.ElseNode)
+	if ok {
+		return true
+	}
+
+	_, ok = localNode.(*node.EndNode)
+	return ok
+}
+
 /**
  * Check if node is a type of `node.SetNode`
  */
@@ -0,0 +1,47 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+/**
+ * A `ResourceLoader` serving files from an `fs.FS`, such as the one
+ * returned by `os.DirFS` or an `embed.FS`. Resource names are paths
+ * within the file system.
+ */
+type FileSystemResourceLoader struct {
+	FileSystem fs.FS
+}
+
+func (loader *FileSystemResourceLoader) OpenResource(name string) (io.ReadCloser, error) {
+	file, err := loader.FileSystem.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func NewFileSystemResourceLoader(fileSystem fs.FS) *FileSystemResourceLoader {
+	return &FileSystemResourceLoader{
+		FileSystem: fileSystem,
+	}
+}
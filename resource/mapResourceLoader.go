@@ -0,0 +1,40 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package resource
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+/**
+ * A `ResourceLoader` serving resources held in memory, keyed by name.
+ */
+type MapResourceLoader struct {
+	Resources map[string]string
+}
+
+func (loader *MapResourceLoader) OpenResource(name string) (io.ReadCloser, error) {
+	contents, ok := loader.Resources[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, name)
+	}
+
+	return io.NopCloser(strings.NewReader(contents)), nil
+}
+
+func NewMapResourceLoader(resources map[string]string) *MapResourceLoader {
+	return &MapResourceLoader{
+		Resources: resources,
+	}
+}
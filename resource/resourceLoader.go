@@ -0,0 +1,54 @@
+/**
+ * velocity4go: Velocity template engine for Go
+ * https://sangupta.com/projects/velocity4go
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository.
+ */
+
+package resource
+
+import (
+	"errors"
+	"io"
+)
+
+/**
+ * Returned, possibly wrapped, by a `ResourceLoader` when there is no
+ * resource with the requested name.
+ */
+var ErrResourceNotFound = errors.New("resource not found")
+
+/**
+ * Gives access to the resources, such as other templates, that a template
+ * refers to by name with directives like {@code #parse}.
+ */
+type ResourceLoader interface {
+	/**
+	 * Opens the resource with the given name for reading. The caller closes
+	 * the returned reader when done with it.
+	 */
+	OpenResource(name string) (io.ReadCloser, error)
+}
+
+/**
+ * Reads the complete contents of the named resource from the given loader.
+ */
+func ReadResource(loader ResourceLoader, name string) (string, error) {
+	reader, err := loader.OpenResource(name)
+	if err != nil {
+		return "", err
+	}
+
+	defer reader.Close()
+
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	return string(contents), nil
+}
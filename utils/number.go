@@ -13,14 +13,56 @@ package utils
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"reflect"
 )
 
+var (
+	/**
+	 * Returned when the divisor of a division or remainder is zero.
+	 */
+	ErrDivisionByZero = errors.New("division by zero")
+
+	/**
+	 * Returned when the result of an operation cannot be represented by the type the
+	 * operands are promoted to.
+	 */
+	ErrOverflow = errors.New("numeric overflow")
+)
+
+/**
+ * A number taken from a template or from the data given to it, which can be of any of
+ * the Go integer or floating-point types. Arithmetic on two numbers promotes them to a
+ * common type as Java does, adapted to the Go types:
+ *
+ * <ul>
+ *   <li>if either number is a {@code float64}, the result is a {@code float64};
+ *   <li>otherwise, if either number is a {@code float32}, the result is a {@code float32};
+ *   <li>otherwise, if both numbers are unsigned, the result is a {@code uint64};
+ *   <li>otherwise the result is an {@code int64}.
+ * </ul>
+ *
+ * <p>A result that does not fit its type is an error rather than wrapping around, as
+ * is dividing by zero. Dividing two integers gives an integer, truncated toward zero.
+ */
 type Number struct {
 	value    interface{}
 	dataType reflect.Type
 }
 
+/**
+ * The types that arithmetic promotes numbers to.
+ */
+type numberKind int
+
+const (
+	signedKind numberKind = iota
+	unsignedKind
+	float32Kind
+	float64Kind
+)
+
 func (num *Number) IsNil() bool {
 	return num.dataType == nil
 }
@@ -37,6 +79,30 @@ func NewNumber(value interface{}) Number {
 	return num
 }
 
+/**
+ * Returns the given value as a `Number`, or false if it is not of a Go numeric type.
+ * Values of named types, like {@code type Celsius float64}, are numbers too.
+ */
+func AsNumber(value interface{}) (Number, bool) {
+	num, ok := value.(Number)
+	if ok {
+		return num, num.HasValue()
+	}
+
+	if value == nil {
+		return NilNumber(), false
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return NewNumber(value), true
+	}
+
+	return NilNumber(), false
+}
+
 func (num *Number) HasValue() bool {
 	return num.dataType != nil
 }
@@ -51,11 +117,384 @@ func (num *Number) SetValue(value interface{}) {
 	num.dataType = reflect.TypeOf(value)
 }
 
+/**
+ * Returns the Go value of the number.
+ */
+func (num *Number) Value() interface{} {
+	return num.value
+}
+
+/**
+ * True if the number is of one of the Go integer types.
+ */
+func (num *Number) IsInteger() bool {
+	kind := num.kind()
+	return kind == signedKind || kind == unsignedKind
+}
+
+/**
+ * Returns the number as an {@code int64}, failing if it is not an integer or is too
+ * large for an {@code int64}.
+ */
+func (num *Number) Int64() (int64, error) {
+	reflected := reflect.ValueOf(num.value)
+	switch num.kind() {
+	case signedKind:
+		return reflected.Int(), nil
+
+	case unsignedKind:
+		unsigned := reflected.Uint()
+		if unsigned > math.MaxInt64 {
+			return 0, ErrOverflow
+		}
+
+		return int64(unsigned), nil
+	}
+
+	return 0, fmt.Errorf("%v is not an integer", num.value)
+}
+
+/**
+ * Returns the number as a {@code float64}, which may lose precision for large integers.
+ */
+func (num *Number) Float64() float64 {
+	reflected := reflect.ValueOf(num.value)
+	switch num.kind() {
+	case signedKind:
+		return float64(reflected.Int())
+
+	case unsignedKind:
+		return float64(reflected.Uint())
+	}
+
+	return reflected.Float()
+}
+
+func (num *Number) Add(other Number) (Number, error) {
+	return num.combine(other, '+')
+}
+
+func (num *Number) Subtract(other Number) (Number, error) {
+	return num.combine(other, '-')
+}
+
+func (num *Number) Multiply(other Number) (Number, error) {
+	return num.combine(other, '*')
+}
+
+func (num *Number) Divide(other Number) (Number, error) {
+	return num.combine(other, '/')
+}
+
+func (num *Number) Remainder(other Number) (Number, error) {
+	return num.combine(other, '%')
+}
+
+/**
+ * Compares this number with another, returning a negative number, zero or a positive
+ * number as this one is less than, equal to or greater than the other. Integers are
+ * compared exactly whatever their types, and other numbers as {@code float64} values.
+ * As with Java's {@code Double.compare}, NaN is greater than any other number and equal
+ * to itself, so that numbers can be sorted; use `Equals` to test for equality.
+ */
+func (num *Number) Compare(other Number) int {
+	left, right := num.kind(), other.kind()
+	if num.IsInteger() && other.IsInteger() {
+		if left == unsignedKind || right == unsignedKind {
+			return compareMixedIntegers(reflect.ValueOf(num.value), left, reflect.ValueOf(other.value), right)
+		}
+
+		return compareOrdered(reflect.ValueOf(num.value).Int(), reflect.ValueOf(other.value).Int())
+	}
+
+	leftNaN, rightNaN := num.isNaN(), other.isNaN()
+	if leftNaN || rightNaN {
+		switch {
+		case leftNaN && rightNaN:
+			return 0
+
+		case leftNaN:
+			return 1
+		}
+
+		return -1
+	}
+
+	return compareOrdered(num.Float64(), other.Float64())
+}
+
+/**
+ * True if this number has the same value as another, whatever their types. As for Go's
+ * and Java's {@code ==}, NaN is not equal to any number, not even itself.
+ */
+func (num *Number) Equals(other Number) bool {
+	if num.isNaN() || other.isNaN() {
+		return false
+	}
+
+	return num.Compare(other) == 0
+}
+
+func (num *Number) String() string {
+	return AsString(num.value)
+}
+
+func (num *Number) isNaN() bool {
+	return !num.IsInteger() && math.IsNaN(num.Float64())
+}
+
 func (num *Number) asInt() (int, error) {
-	i, ok := num.value.(int)
-	if ok {
-		return i, nil
+	value, err := num.Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	if value < math.MinInt || value > math.MaxInt {
+		return 0, ErrOverflow
+	}
+
+	return int(value), nil
+}
+
+func (num *Number) kind() numberKind {
+	switch num.dataType.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return unsignedKind
+
+	case reflect.Float32:
+		return float32Kind
+
+	case reflect.Float64:
+		return float64Kind
+	}
+
+	return signedKind
+}
+
+/**
+ * Applies one of the operators {@code + - * / %} to this number and another, after
+ * promoting both to their common type.
+ */
+func (num *Number) combine(other Number, operator rune) (Number, error) {
+	if !num.HasValue() || !other.HasValue() {
+		return NilNumber(), errors.New("arithmetic on a nil number")
+	}
+
+	left, right := num.kind(), other.kind()
+	switch {
+	case left == float64Kind || right == float64Kind:
+		result, err := combineFloats(num.Float64(), other.Float64(), operator)
+		return NewNumber(result), err
+
+	case left == float32Kind || right == float32Kind:
+		result, err := combineFloats(num.Float64(), other.Float64(), operator)
+		if err == nil && math.Abs(result) > math.MaxFloat32 && !math.IsInf(result, 0) {
+			err = ErrOverflow
+		}
+
+		return NewNumber(float32(result)), err
+
+	case left == unsignedKind && right == unsignedKind:
+		result, err := combineUnsigned(reflect.ValueOf(num.value).Uint(), reflect.ValueOf(other.value).Uint(), operator)
+		if err != nil {
+			return NilNumber(), err
+		}
+
+		return NewNumber(result), nil
+	}
+
+	leftValue, err := num.Int64()
+	if err != nil {
+		return NilNumber(), err
+	}
+
+	rightValue, err := other.Int64()
+	if err != nil {
+		return NilNumber(), err
+	}
+
+	result, err := combineSigned(leftValue, rightValue, operator)
+	if err != nil {
+		return NilNumber(), err
+	}
+
+	return NewNumber(result), nil
+}
+
+func combineSigned(left int64, right int64, operator rune) (int64, error) {
+	switch operator {
+	case '+':
+		result := left + right
+		if (result > left) != (right > 0) {
+			return 0, ErrOverflow
+		}
+
+		return result, nil
+
+	case '-':
+		result := left - right
+		if (result < left) != (right > 0) {
+			return 0, ErrOverflow
+		}
+
+		return result, nil
+
+	case '*':
+		if left == 0 || right == 0 {
+			return 0, nil
+		}
+
+		result := left * right
+		if result/right != left || (left == -1 && right == math.MinInt64) || (right == -1 && left == math.MinInt64) {
+			return 0, ErrOverflow
+		}
+
+		return result, nil
+
+	case '/':
+		if right == 0 {
+			return 0, ErrDivisionByZero
+		}
+
+		if left == math.MinInt64 && right == -1 {
+			return 0, ErrOverflow
+		}
+
+		return left / right, nil
+
+	default:
+		if right == 0 {
+			return 0, ErrDivisionByZero
+		}
+
+		if right == -1 {
+			return 0, nil
+		}
+
+		return left % right, nil
+	}
+}
+
+/**
+ * Combines two unsigned integers. Since the difference of two unsigned integers can be
+ * negative, as {@code 3 - 5} is, subtraction gives an {@code int64} in that case.
+ */
+func combineUnsigned(left uint64, right uint64, operator rune) (interface{}, error) {
+	switch operator {
+	case '+':
+		result := left + right
+		if result < left {
+			return nil, ErrOverflow
+		}
+
+		return result, nil
+
+	case '-':
+		if left >= right {
+			return left - right, nil
+		}
+
+		difference := right - left
+		if difference > 1<<63 {
+			return nil, ErrOverflow
+		}
+
+		return -int64(difference-1) - 1, nil
+
+	case '*':
+		if left == 0 || right == 0 {
+			return uint64(0), nil
+		}
+
+		result := left * right
+		if result/right != left {
+			return nil, ErrOverflow
+		}
+
+		return result, nil
+
+	case '/':
+		if right == 0 {
+			return nil, ErrDivisionByZero
+		}
+
+		return left / right, nil
+
+	default:
+		if right == 0 {
+			return nil, ErrDivisionByZero
+		}
+
+		return left % right, nil
+	}
+}
+
+func combineFloats(left float64, right float64, operator rune) (float64, error) {
+	var result float64
+	switch operator {
+	case '+':
+		result = left + right
+
+	case '-':
+		result = left - right
+
+	case '*':
+		result = left * right
+
+	case '/':
+		if right == 0 {
+			return 0, ErrDivisionByZero
+		}
+
+		result = left / right
+
+	default:
+		if right == 0 {
+			return 0, ErrDivisionByZero
+		}
+
+		result = math.Mod(left, right)
+	}
+
+	if math.IsInf(result, 0) && !math.IsInf(left, 0) && !math.IsInf(right, 0) {
+		return 0, ErrOverflow
+	}
+
+	return result, nil
+}
+
+/**
+ * Compares two integers of which at least one is unsigned. A negative signed integer is
+ * less than any unsigned one, and otherwise both can be compared as {@code uint64} values.
+ */
+func compareMixedIntegers(left reflect.Value, leftKind numberKind, right reflect.Value, rightKind numberKind) int {
+	if leftKind == signedKind {
+		if left.Int() < 0 {
+			return -1
+		}
+
+		return compareOrdered(uint64(left.Int()), right.Uint())
+	}
+
+	if rightKind == signedKind {
+		if right.Int() < 0 {
+			return 1
+		}
+
+		return compareOrdered(left.Uint(), uint64(right.Int()))
+	}
+
+	return compareOrdered(left.Uint(), right.Uint())
+}
+
+func compareOrdered[T int64 | uint64 | float64](left T, right T) int {
+	if left < right {
+		return -1
+	}
+
+	if left > right {
+		return 1
 	}
 
-	return 0, errors.New("Cannot convert value to int")
+	return 0
 }
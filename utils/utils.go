@@ -14,6 +14,11 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // ----------------
@@ -65,9 +70,15 @@ func ParseInt(str string) int {
 	panic(ParseException("Invalid integer: " + str))
 }
 
+/**
+ * Returns the text a value renders as, which for nil is {@code null}, for a floating-point
+ * number is as described for `formatFloat`, for a slice or array, or a pointer to a slice,
+ * is a list like {@code [a, b]}, and for a map, or a pointer to a map, is like
+ * {@code {a=1, b=2}}, as in Java.
+ */
 func AsString(value interface{}) string {
 	if value == nil {
-		return "nil"
+		return "null"
 	}
 
 	str, ok := value.(string)
@@ -89,5 +100,149 @@ func AsString(value interface{}) string {
 		return fmt.Sprint(intx)
 	}
 
-	return "unknown"
+	switch float := value.(type) {
+	case float64:
+		return formatFloat(float, 64)
+
+	case float32:
+		return formatFloat(float64(float), 32)
+	}
+
+	stringer, ok := value.(fmt.Stringer)
+	if ok {
+		return stringer.String()
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() == reflect.Ptr && !reflected.IsNil() {
+		switch reflected.Elem().Kind() {
+		case reflect.Slice, reflect.Map:
+			reflected = reflected.Elem()
+		}
+	}
+
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array:
+		return listAsString(reflected)
+
+	case reflect.Map:
+		return mapAsString(reflected)
+	}
+
+	return fmt.Sprint(value)
+}
+
+/**
+ * Renders a floating-point number as Java's {@code Double.toString} does, or
+ * {@code Float.toString} for a {@code bitSize} of 32: always with a fractional part,
+ * like {@code 1.0}, and in scientific notation, like {@code 1.0E7} or {@code 2.5E-4},
+ * outside the range from 10<sup>-3</sup> to 10<sup>7</sup>.
+ */
+func formatFloat(value float64, bitSize int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+
+	case math.IsInf(value, 1):
+		return "Infinity"
+
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	}
+
+	magnitude := math.Abs(value)
+	if magnitude == 0 || (magnitude >= 1e-3 && magnitude < 1e7) {
+		str := strconv.FormatFloat(value, 'f', -1, bitSize)
+		if !strings.Contains(str, ".") {
+			str += ".0"
+		}
+
+		return str
+	}
+
+	// Go gives the shortest digits as 1.5e+07, which Java writes 1.5E7
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(value, 'e', -1, bitSize), "e")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+
+	power, _ := strconv.Atoi(exponent)
+	return mantissa + "E" + strconv.Itoa(power)
+}
+
+/**
+ * Renders a slice or array as Java renders a list, like {@code [a, b, c]}.
+ */
+func listAsString(list reflect.Value) string {
+	builder := strings.Builder{}
+	builder.WriteRune('[')
+	for index := 0; index < list.Len(); index++ {
+		if index > 0 {
+			builder.WriteString(", ")
+		}
+
+		builder.WriteString(AsString(list.Index(index).Interface()))
+	}
+	builder.WriteRune(']')
+
+	return builder.String()
+}
+
+/**
+ * Renders a map as Java's {@code AbstractMap} does, like {@code {a=1, b=2}}, with the keys
+ * in the order given by `SortedMapKeys`.
+ */
+func mapAsString(reflected reflect.Value) string {
+	builder := strings.Builder{}
+	builder.WriteRune('{')
+	for index, key := range SortedMapKeys(reflected) {
+		if index > 0 {
+			builder.WriteString(", ")
+		}
+
+		builder.WriteString(AsString(key.Interface()))
+		builder.WriteRune('=')
+		builder.WriteString(AsString(reflected.MapIndex(key).Interface()))
+	}
+	builder.WriteRune('}')
+
+	return builder.String()
+}
+
+/**
+ * Returns the keys of a map sorted by their value, so that iterating over a map gives the
+ * same result each time.
+ */
+func SortedMapKeys(reflected reflect.Value) []reflect.Value {
+	keys := reflected.MapKeys()
+
+	sort.Slice(keys, func(i, j int) bool {
+		left, right := keys[i], keys[j]
+		for left.Kind() == reflect.Interface && !left.IsNil() {
+			left = left.Elem()
+		}
+		for right.Kind() == reflect.Interface && !right.IsNil() {
+			right = right.Elem()
+		}
+
+		if left.Kind() == right.Kind() {
+			switch left.Kind() {
+			case reflect.String:
+				return left.String() < right.String()
+
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return left.Int() < right.Int()
+
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				return left.Uint() < right.Uint()
+
+			case reflect.Float32, reflect.Float64:
+				return left.Float() < right.Float()
+			}
+		}
+
+		return fmt.Sprint(left.Interface()) < fmt.Sprint(right.Interface())
+	})
+
+	return keys
 }
@@ -13,6 +13,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -33,18 +34,23 @@ func main() {
 func parseAndRender(template string, vars map[string]interface{}, showTemplate bool, render bool) {
 	// our local code
 	chars := []rune(template)
-	parser := parser.Parser{
+	templateParser := parser.Parser{
 		Chars:        chars,
 		ResourceName: "template.vm",
 	}
 
 	start := time.Now()
-	parsedTemplate, err := parser.Parse()
+	parsedTemplate, err := templateParser.Parse()
 	duration := time.Since(start)
 
 	fmt.Println("time taken to parse: " + duration.String())
 	if err != nil {
-		fmt.Println("Failed")
+		fmt.Println("Failed: " + err.Error())
+
+		var parseError *parser.ParseError
+		if errors.As(err, &parseError) {
+			fmt.Println(parseError.Excerpt)
+		}
 		return
 	}
 
@@ -63,10 +69,15 @@ func parseAndRender(template string, vars map[string]interface{}, showTemplate b
 	}
 
 	startRender := time.Now()
-	rendered := parsedTemplate.Evaluate(vars)
+	rendered, err := parsedTemplate.Execute(vars)
 	durationRender := time.Since(startRender)
 
 	fmt.Println("time taken to render: " + durationRender.String())
+	if err != nil {
+		fmt.Println("Failed: " + err.Error())
+		return
+	}
+
 	fmt.Println()
 	fmt.Println("rendered: " + rendered)
 	fmt.Println()